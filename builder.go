@@ -0,0 +1,402 @@
+package vast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBuildVersion is the version of the documents built when none is set
+const DefaultBuildVersion = "3.0"
+
+// ValidationError is returned by builders when the document they build
+// violates the spec
+type ValidationError struct {
+	// The issues of severity error reported by Validate
+	Issues []Issue
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.Path + ": " + issue.Message
+	}
+	return "invalid VAST: " + strings.Join(msgs, "; ")
+}
+
+// build wraps an ad in a VAST document and validates it
+func build(version string, ad Ad, err error) (*VAST, error) {
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = DefaultBuildVersion
+	}
+
+	// copy so that further builder calls don't modify the returned document
+	v := copyVAST(&VAST{Version: version, Ads: []Ad{ad}})
+	var errs []Issue
+	for _, issue := range Validate(v, "") {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) != 0 {
+		return nil, &ValidationError{Issues: errs}
+	}
+	return v, nil
+}
+
+func buildAd(v *VAST, err error) (*Ad, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &v.Ads[0], nil
+}
+
+// InLineBuilder builds a VAST document made of a single InLine ad, e.g.
+//
+//	v, err := vast.NewInLine("ad-1").
+//		System("adserver", "1.0").
+//		Title("Spring sale").
+//		Impression("http://example.com/impression").
+//		Linear(30 * time.Second).
+//		MediaFile(vast.MediaFile{Delivery: "progressive", Type: "video/mp4", Width: 1280, Height: 720, URI: "http://example.com/ad.mp4"}).
+//		Track(vast.EventStart, "http://example.com/start").
+//		Build()
+//
+// Creative level methods apply to the last creative added by Linear.
+type InLineBuilder struct {
+	version string
+	ad      Ad
+	err     error
+}
+
+// NewInLine starts building an InLine ad with the given identifier
+func NewInLine(id string) *InLineBuilder {
+	return &InLineBuilder{ad: Ad{ID: id, InLine: &InLine{}}}
+}
+
+// Version sets the VAST version of the document, DefaultBuildVersion by
+// default
+func (b *InLineBuilder) Version(version string) *InLineBuilder {
+	b.version = version
+	return b
+}
+
+// Sequence sets the position of the ad in a pod
+func (b *InLineBuilder) Sequence(seq int) *InLineBuilder {
+	b.ad.Sequence = seq
+	return b
+}
+
+// System sets the name and version of the ad server
+func (b *InLineBuilder) System(name, version string) *InLineBuilder {
+	b.ad.InLine.AdSystem = &AdSystem{Name: name, Version: version}
+	return b
+}
+
+// Title sets the common name of the ad
+func (b *InLineBuilder) Title(title string) *InLineBuilder {
+	b.ad.InLine.AdTitle = &AdTitle{Name: title}
+	return b
+}
+
+// Description sets the longer description of the ad
+func (b *InLineBuilder) Description(desc string) *InLineBuilder {
+	b.ad.InLine.Description = desc
+	return b
+}
+
+// Advertiser sets the name of the advertiser
+func (b *InLineBuilder) Advertiser(name string) *InLineBuilder {
+	b.ad.InLine.Advertiser = &Advertiser{Name: name}
+	return b
+}
+
+// AdServingID sets the identifier of the ad across the supply chain (VAST 4)
+func (b *InLineBuilder) AdServingID(id string) *InLineBuilder {
+	b.ad.InLine.AdServingID = id
+	return b
+}
+
+// Error adds an error tracking URI
+func (b *InLineBuilder) Error(uri URI) *InLineBuilder {
+	b.ad.InLine.Error = append(b.ad.InLine.Error, Error{URI: uri})
+	return b
+}
+
+// Impression adds an impression tracking URI
+func (b *InLineBuilder) Impression(uri URI) *InLineBuilder {
+	b.ad.InLine.Impressions = append(b.ad.InLine.Impressions, Impression{URI: uri})
+	return b
+}
+
+// Extension adds a custom extension
+func (b *InLineBuilder) Extension(typ string, data []byte) *InLineBuilder {
+	if b.ad.InLine.Extensions == nil {
+		b.ad.InLine.Extensions = &Extensions{}
+	}
+	b.ad.InLine.Extensions.Extensions = append(b.ad.InLine.Extensions.Extensions, Extension{Type: typ, Data: data})
+	return b
+}
+
+// Linear adds a linear creative of the given duration
+func (b *InLineBuilder) Linear(d time.Duration) *InLineBuilder {
+	dur := Duration(d)
+	b.ad.InLine.Creatives = append(b.ad.InLine.Creatives, Creative{Linear: &Linear{Duration: &dur}})
+	return b
+}
+
+// UniversalAdID adds a universal identifier to the creative, required by
+// VAST 4
+func (b *InLineBuilder) UniversalAdID(registry, id string) *InLineBuilder {
+	if c := b.creative("UniversalAdID"); c != nil {
+		c.UniversalAdIDs = append(c.UniversalAdIDs, UniversalAdID{IDRegistry: registry, ID: id})
+	}
+	return b
+}
+
+// SkipOffset makes the linear creative skippable after the given offset
+func (b *InLineBuilder) SkipOffset(offset Offset) *InLineBuilder {
+	if l := b.linear("SkipOffset"); l != nil {
+		l.SkipOffset = &offset
+	}
+	return b
+}
+
+// MediaFile adds a media file to the linear creative
+func (b *InLineBuilder) MediaFile(f MediaFile) *InLineBuilder {
+	if l := b.linear("MediaFile"); l != nil {
+		l.MediaFiles = append(l.MediaFiles, f)
+	}
+	return b
+}
+
+// Track adds a tracking URI for an event of the linear creative
+func (b *InLineBuilder) Track(event EventType, uri URI) *InLineBuilder {
+	if l := b.linear("Track"); l != nil {
+		l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: event, URI: uri})
+	}
+	return b
+}
+
+// Progress adds a tracking URI for the progress event of the linear
+// creative at the given offset
+func (b *InLineBuilder) Progress(offset Offset, uri URI) *InLineBuilder {
+	if l := b.linear("Progress"); l != nil {
+		l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: EventProgress, Offset: &offset, URI: uri})
+	}
+	return b
+}
+
+// ClickThrough sets the landing page of the linear creative
+func (b *InLineBuilder) ClickThrough(uri URI) *InLineBuilder {
+	if l := b.linear("ClickThrough"); l != nil {
+		l.VideoClicks = videoClicks(l.VideoClicks)
+		l.VideoClicks.ClickThroughs = []VideoClick{{URI: uri}}
+	}
+	return b
+}
+
+// ClickTracking adds a click tracking URI to the linear creative
+func (b *InLineBuilder) ClickTracking(uri URI) *InLineBuilder {
+	if l := b.linear("ClickTracking"); l != nil {
+		l.VideoClicks = videoClicks(l.VideoClicks)
+		l.VideoClicks.ClickTrackings = append(l.VideoClicks.ClickTrackings, VideoClick{URI: uri})
+	}
+	return b
+}
+
+// Build returns the VAST document, or the first misuse of the builder, or a
+// ValidationError if required fields are missing.
+func (b *InLineBuilder) Build() (*VAST, error) {
+	return build(b.version, b.ad, b.err)
+}
+
+// BuildAd is like Build but returns the ad alone
+func (b *InLineBuilder) BuildAd() (*Ad, error) {
+	return buildAd(b.Build())
+}
+
+// creative returns the last creative, recording an error if there is none
+func (b *InLineBuilder) creative(method string) *Creative {
+	n := len(b.ad.InLine.Creatives)
+	if n == 0 {
+		b.fail(method)
+		return nil
+	}
+	return &b.ad.InLine.Creatives[n-1]
+}
+
+// linear returns the last linear creative, recording an error if there is
+// none
+func (b *InLineBuilder) linear(method string) *Linear {
+	c := b.creative(method)
+	if c == nil {
+		return nil
+	}
+	if c.Linear == nil {
+		b.fail(method)
+	}
+	return c.Linear
+}
+
+func (b *InLineBuilder) fail(method string) {
+	if b.err == nil {
+		b.err = fmt.Errorf("%s called before Linear", method)
+	}
+}
+
+func videoClicks(vc *VideoClicks) *VideoClicks {
+	if vc == nil {
+		return &VideoClicks{}
+	}
+	return vc
+}
+
+// WrapperBuilder builds a VAST document made of a single Wrapper ad, which
+// wraps a third-party tag with our own trackers, e.g.
+//
+//	ad, err := vast.NewWrapper("ad-1", "http://example.com/tag.xml").
+//		System("adserver", "1.0").
+//		Impression("http://example.com/impression").
+//		Error("http://example.com/error?code=[ERRORCODE]").
+//		Trackers(map[vast.EventType][]vast.URI{
+//			vast.EventFirstQuartile: {"http://example.com/q1"},
+//			vast.EventComplete:      {"http://example.com/complete"},
+//		}).
+//		ClickTracking("http://example.com/click").
+//		BuildAd()
+type WrapperBuilder struct {
+	version string
+	ad      Ad
+}
+
+// NewWrapper starts building a Wrapper ad with the given identifier around
+// a tag URI
+func NewWrapper(id string, tag URI) *WrapperBuilder {
+	return &WrapperBuilder{ad: Ad{ID: id, Wrapper: &Wrapper{VASTAdTagURI: TagURI{Name: tag}}}}
+}
+
+// Version sets the VAST version of the document, DefaultBuildVersion by
+// default
+func (b *WrapperBuilder) Version(version string) *WrapperBuilder {
+	b.version = version
+	return b
+}
+
+// Sequence sets the position of the ad in a pod
+func (b *WrapperBuilder) Sequence(seq int) *WrapperBuilder {
+	b.ad.Sequence = seq
+	return b
+}
+
+// System sets the name and version of the ad server
+func (b *WrapperBuilder) System(name, version string) *WrapperBuilder {
+	b.ad.Wrapper.AdSystem = &AdSystem{Name: name, Version: version}
+	return b
+}
+
+// Error adds an error tracking URI
+func (b *WrapperBuilder) Error(uri URI) *WrapperBuilder {
+	b.ad.Wrapper.Error = append(b.ad.Wrapper.Error, Error{URI: uri})
+	return b
+}
+
+// Impression adds an impression tracking URI
+func (b *WrapperBuilder) Impression(uri URI) *WrapperBuilder {
+	b.ad.Wrapper.Impressions = append(b.ad.Wrapper.Impressions, Impression{URI: uri})
+	return b
+}
+
+// Extension adds a custom extension
+func (b *WrapperBuilder) Extension(typ string, data []byte) *WrapperBuilder {
+	if b.ad.Wrapper.Extensions == nil {
+		b.ad.Wrapper.Extensions = &Extensions{}
+	}
+	b.ad.Wrapper.Extensions.Extensions = append(b.ad.Wrapper.Extensions.Extensions, Extension{Type: typ, Data: data})
+	return b
+}
+
+// Track adds a tracking URI for an event of the wrapped linear creative
+func (b *WrapperBuilder) Track(event EventType, uri URI) *WrapperBuilder {
+	l := b.linear()
+	l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: event, URI: uri})
+	return b
+}
+
+// Trackers adds tracking URIs for events of the wrapped linear creative,
+// ordered by event name
+func (b *WrapperBuilder) Trackers(events map[EventType][]URI) *WrapperBuilder {
+	names := make([]string, 0, len(events))
+	for event := range events {
+		names = append(names, string(event))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, uri := range events[EventType(name)] {
+			b.Track(EventType(name), uri)
+		}
+	}
+	return b
+}
+
+// Progress adds a tracking URI for the progress event of the wrapped linear
+// creative at the given offset
+func (b *WrapperBuilder) Progress(offset Offset, uri URI) *WrapperBuilder {
+	l := b.linear()
+	l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: EventProgress, Offset: &offset, URI: uri})
+	return b
+}
+
+// ClickTracking adds a click tracking URI to the wrapped linear creative
+func (b *WrapperBuilder) ClickTracking(uri URI) *WrapperBuilder {
+	l := b.linear()
+	l.VideoClicks = videoClicks(l.VideoClicks)
+	l.VideoClicks.ClickTrackings = append(l.VideoClicks.ClickTrackings, VideoClick{URI: uri})
+	return b
+}
+
+// FollowAdditionalWrappers sets whether the wrapped tag may return another
+// wrapper (VAST 4)
+func (b *WrapperBuilder) FollowAdditionalWrappers(follow bool) *WrapperBuilder {
+	b.ad.Wrapper.FollowAdditionalWrappers = &follow
+	return b
+}
+
+// AllowMultipleAds sets whether the wrapped tag may return a pod (VAST 4)
+func (b *WrapperBuilder) AllowMultipleAds(allow bool) *WrapperBuilder {
+	b.ad.Wrapper.AllowMultipleAds = &allow
+	return b
+}
+
+// FallbackOnNoAd sets whether another ad may be played when the wrapped tag
+// returns no ads (VAST 4)
+func (b *WrapperBuilder) FallbackOnNoAd(fallback bool) *WrapperBuilder {
+	b.ad.Wrapper.FallbackOnNoAd = &fallback
+	return b
+}
+
+// Build returns the VAST document, or a ValidationError if required fields
+// are missing.
+func (b *WrapperBuilder) Build() (*VAST, error) {
+	return build(b.version, b.ad, nil)
+}
+
+// BuildAd is like Build but returns the ad alone
+func (b *WrapperBuilder) BuildAd() (*Ad, error) {
+	return buildAd(b.Build())
+}
+
+// linear returns the wrapped linear creative, adding it if needed
+func (b *WrapperBuilder) linear() *LinearWrapper {
+	w := b.ad.Wrapper
+	if len(w.Creatives) == 0 {
+		w.Creatives = []CreativeWrapper{{Linear: &LinearWrapper{}}}
+	}
+	return w.Creatives[0].Linear
+}
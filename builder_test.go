@@ -0,0 +1,150 @@
+package vast
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InLineBuilder", func() {
+	mp4 := MediaFile{Delivery: "progressive", Type: "video/mp4", Width: 1280, Height: 720, URI: "http://example.com/ad.mp4"}
+
+	It("should build inline ads", func() {
+		v, err := NewInLine("1").
+			System("adserver", "1.0").
+			Title("title").
+			Advertiser("advertiser").
+			Error("http://example.com/error?code=[ERRORCODE]").
+			Impression("http://example.com/impression").
+			Linear(15*time.Second).
+			SkipOffset(Offset{Duration: durationPtr(5 * time.Second)}).
+			MediaFile(mp4).
+			Track(EventStart, "http://example.com/start").
+			Progress(Offset{Percent: 0.5}, "http://example.com/half").
+			ClickThrough("http://example.com/landing").
+			ClickTracking("http://example.com/click").
+			Extension("custom", []byte("<Custom/>")).
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Version).To(Equal("3.0"))
+		Expect(v.Ads).To(HaveLen(1))
+
+		in := v.Ads[0].InLine
+		Expect(in.AdSystem).To(Equal(&AdSystem{Name: "adserver", Version: "1.0"}))
+		Expect(in.Creatives).To(HaveLen(1))
+		Expect(*in.Creatives[0].Linear.Duration).To(Equal(Duration(15 * time.Second)))
+		Expect(in.Creatives[0].Linear.TrackersFor(EventStart)).To(Equal([]URI{"http://example.com/start"}))
+		Expect(in.Creatives[0].Linear.VideoClicks.ClickThroughs).To(Equal([]VideoClick{{URI: "http://example.com/landing"}}))
+
+		b, err := Marshal(v)
+		Expect(err).NotTo(HaveOccurred())
+		v2, err := Decode(bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v2).To(Equal(v))
+	})
+
+	It("should validate required fields", func() {
+		_, err := NewInLine("1").Title("title").Linear(0).Build()
+		Expect(err).To(BeAssignableToTypeOf(&ValidationError{}))
+		Expect(err.(*ValidationError).Issues).To(HaveLen(3))
+		Expect(err).To(MatchError("invalid VAST: " +
+			"VAST/Ad[1]/InLine: missing AdSystem; " +
+			"VAST/Ad[1]/InLine: missing Impression; " +
+			"VAST/Ad[1]/InLine/Creatives/Creative[1]/Linear: missing MediaFiles"))
+
+		_, err = NewInLine("1").System("adserver", "").Title("title").Impression("http://example.com/impression").
+			Version("4.1").Linear(time.Second).MediaFile(mp4).Build()
+		Expect(err).To(MatchError("invalid VAST: VAST/Ad[1]/InLine/Creatives/Creative[1]: missing UniversalAdId"))
+	})
+
+	It("should report misuse", func() {
+		_, err := NewInLine("1").MediaFile(mp4).Linear(time.Second).Track(EventStart, "http://example.com/start").Build()
+		Expect(err).To(MatchError("MediaFile called before Linear"))
+	})
+
+	It("should not modify built documents", func() {
+		b := NewInLine("1").System("adserver", "").Title("title").Impression("http://example.com/impression").
+			Linear(time.Second).MediaFile(mp4)
+		v, err := b.Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		b.Title("other").Track(EventStart, "http://example.com/start").MediaFile(mp4)
+		Expect(v.Ads[0].InLine.AdTitle.Name).To(Equal("title"))
+		Expect(v.Ads[0].InLine.Creatives[0].Linear.TrackingEvents).To(BeEmpty())
+		Expect(v.Ads[0].InLine.Creatives[0].Linear.MediaFiles).To(HaveLen(1))
+	})
+
+})
+
+var _ = Describe("WrapperBuilder", func() {
+
+	It("should wrap tags", func() {
+		ad, err := NewWrapper("1", "http://example.com/tag.xml").
+			System("adserver", "").
+			Impression("http://example.com/impression").
+			Error("http://example.com/error?code=[ERRORCODE]").
+			Trackers(map[EventType][]URI{
+				EventMidpoint:      {"http://example.com/mid"},
+				EventFirstQuartile: {"http://example.com/q1", "http://example.com/q1b"},
+			}).
+			ClickTracking("http://example.com/click").
+			Version("4.1").
+			FollowAdditionalWrappers(false).
+			AllowMultipleAds(true).
+			FallbackOnNoAd(true).
+			BuildAd()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ad.Wrapper.VASTAdTagURI.Name).To(Equal(URI("http://example.com/tag.xml")))
+		Expect(ad.Wrapper.Creatives).To(Equal([]CreativeWrapper{{Linear: &LinearWrapper{
+			TrackingEvents: []Tracking{
+				{Event: EventFirstQuartile, URI: "http://example.com/q1"},
+				{Event: EventFirstQuartile, URI: "http://example.com/q1b"},
+				{Event: EventMidpoint, URI: "http://example.com/mid"},
+			},
+			VideoClicks: &VideoClicks{ClickTrackings: []VideoClick{{URI: "http://example.com/click"}}},
+		}}}))
+
+		b, err := Marshal(&VAST{Version: "4.1", Ads: []Ad{*ad}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`<Wrapper followAdditionalWrappers="false" allowMultipleAds="true" fallbackOnNoAd="true">`))
+
+		v, err := Decode(bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0]).To(Equal(*ad))
+	})
+
+	It("should validate required fields", func() {
+		_, err := NewWrapper("1", "").Track(EventStart, "http://example.com/start").Build()
+		Expect(err).To(MatchError("invalid VAST: " +
+			"VAST/Ad[1]/Wrapper: missing AdSystem; " +
+			"VAST/Ad[1]/Wrapper: missing VASTAdTagURI; " +
+			"VAST/Ad[1]/Wrapper: missing Impression"))
+	})
+
+	It("should reject VAST 4 attributes in older versions", func() {
+		b := NewWrapper("1", "http://example.com/tag.xml").System("adserver", "").Impression("http://example.com/impression").
+			FollowAdditionalWrappers(false).
+			FallbackOnNoAd(true)
+		_, err := b.Build()
+		Expect(err).To(MatchError("invalid VAST: " +
+			"VAST/Ad[1]/Wrapper: followAdditionalWrappers attribute requires VAST 4; " +
+			"VAST/Ad[1]/Wrapper: fallbackOnNoAd attribute requires VAST 4"))
+
+		_, err = b.Version("4.0").Build()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should not modify built documents", func() {
+		b := NewWrapper("1", "http://example.com/tag.xml").System("adserver", "").Impression("http://example.com/impression").
+			Track(EventStart, "http://example.com/start")
+		ad, err := b.BuildAd()
+		Expect(err).NotTo(HaveOccurred())
+
+		b.Track(EventComplete, "http://example.com/complete").FollowAdditionalWrappers(false)
+		Expect(ad.Wrapper.Creatives[0].Linear.TrackingEvents).To(HaveLen(1))
+		Expect(ad.Wrapper.FollowAdditionalWrappers).To(BeNil())
+	})
+
+})
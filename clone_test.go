@@ -0,0 +1,32 @@
+package vast
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("copyVAST", func() {
+
+	It("should copy deeply", func() {
+		v, err := FromXMLPreserving(mustReadFile("testdata/vast4_inline_linear.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		c := copyVAST(v)
+		Expect(c).To(Equal(v))
+
+		in := c.Ads[0].InLine
+		in.AdTitle.Name = "copy"
+		in.Impressions[0].URI = "http://copy"
+		in.Creatives[0].Linear.MediaFiles = nil
+		in.AdVerifications.Verifications[0].VerificationParameters.Parameters[0] = 'x'
+		Expect(v.Ads[0].InLine.AdTitle.Name).NotTo(Equal("copy"))
+		Expect(v.Ads[0].InLine.Impressions[0].URI).NotTo(Equal(URI("http://copy")))
+		Expect(v.Ads[0].InLine.Creatives[0].Linear.MediaFiles).NotTo(BeEmpty())
+		Expect(v.Ads[0].InLine.AdVerifications.Verifications[0].VerificationParameters.Parameters[0]).To(Equal(byte('{')))
+	})
+
+	It("should keep nil values", func() {
+		Expect(copyVAST(&VAST{Version: "3.0"})).To(Equal(&VAST{Version: "3.0"}))
+	})
+
+})
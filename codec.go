@@ -0,0 +1,182 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+)
+
+// Namespace is the XML namespace of VAST 4 documents
+const Namespace = "http://www.iab.com/VAST"
+
+// Decode parses a VAST document from a reader. Unlike FromXML, the document
+// is streamed as is, so whitespace and CDATA payloads such as HTMLResource,
+// AdParameters or Extension data are preserved byte-for-byte. Errors are
+// reported as a *ParseError, giving the path and the offending text only
+// when r is also an io.Seeker, since the input is read again to find them.
+func Decode(r io.Reader) (*VAST, error) {
+	start := int64(-1)
+	if s, ok := r.(io.Seeker); ok {
+		if pos, err := s.Seek(0, io.SeekCurrent); err == nil {
+			start = pos
+		}
+	}
+	c := newCountingReader(r)
+	d := xml.NewDecoder(c)
+
+	var v VAST
+	if err := d.Decode(&v); err != nil {
+		return nil, decodeError(r, start, c, d.InputOffset(), err)
+	}
+	return &v, nil
+}
+
+// decodeError locates err, which occurred at offset in the document read
+// from r. The document is read again from start if r is seekable.
+func decodeError(r io.Reader, start int64, c *countingReader, offset int64, err error) *ParseError {
+	if start >= 0 {
+		if _, serr := r.(io.Seeker).Seek(start, io.SeekStart); serr == nil {
+			if doc, rerr := ioutil.ReadAll(r); rerr == nil {
+				return newParseError(doc, doc, offset, err, func(offset int64) int64 { return offset })
+			}
+		}
+	}
+	e := &ParseError{Code: parseErrorCode(err), Err: err}
+	e.Line, e.Column = c.position(offset)
+	return e
+}
+
+// Encode writes the XML encoding of v to a writer, preceded by the standard
+// XML header. Unlike MarshalXML, element contents are written as is.
+//
+// VAST 4 documents are declared in the VAST namespace, unless overridden by
+// the WithNamespace option.
+func Encode(w io.Writer, v *VAST, opts ...Option) error {
+	o := newEncodeOptions(v, opts)
+	if o.canonical {
+		v = canonicalCopy(v)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	// output needs to be rewritten, encode into a buffer first
+	if o.rewrites() {
+		buf := new(bytes.Buffer)
+		if err := o.encode(buf, v); err != nil {
+			return err
+		}
+		_, err := w.Write(rewrite(buf.Bytes(), o))
+		return err
+	}
+	return o.encode(w, v)
+}
+
+// Marshal returns the XML encoding of v, see Encode
+func Marshal(v *VAST, opts ...Option) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, v, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Option configures Encode
+type Option func(*encodeOptions)
+
+// WithNamespace sets the XML namespace declared by the root element. An empty
+// namespace omits the declaration.
+func WithNamespace(ns string) Option {
+	return func(o *encodeOptions) {
+		o.namespace = ns
+	}
+}
+
+// WithIndent indents the output, each element begins on a new line starting
+// with prefix followed by one or more copies of indent according to the
+// nesting depth.
+func WithIndent(prefix, indent string) Option {
+	return func(o *encodeOptions) {
+		o.prefix = prefix
+		o.indent = indent
+	}
+}
+
+// Canonical produces a stable output for equivalent documents: extensions
+// are sorted by type and content, and empty container elements such as
+// <TrackingEvents></TrackingEvents> are omitted. Attributes are always
+// written in a fixed order.
+func Canonical() Option {
+	return func(o *encodeOptions) {
+		o.canonical = true
+	}
+}
+
+// WithCDATA writes the text of the named elements as CDATA sections,
+// e.g. WithCDATA("Pricing", "Category").
+func WithCDATA(elements ...string) Option {
+	return func(o *encodeOptions) {
+		o.setTextMode(textCDATA, elements)
+	}
+}
+
+// WithEscapedText writes the text of the named elements as escaped text
+// instead of CDATA sections, e.g. WithEscapedText("Impression", "Tracking").
+func WithEscapedText(elements ...string) Option {
+	return func(o *encodeOptions) {
+		o.setTextMode(textEscaped, elements)
+	}
+}
+
+type textMode int
+
+const (
+	textCDATA textMode = iota + 1
+	textEscaped
+)
+
+type encodeOptions struct {
+	namespace      string
+	prefix, indent string
+	canonical      bool
+	text           map[string]textMode
+}
+
+func newEncodeOptions(v *VAST, opts []Option) *encodeOptions {
+	o := new(encodeOptions)
+	if majorVersion(v.Version) >= 4 {
+		o.namespace = Namespace
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *encodeOptions) setTextMode(mode textMode, elements []string) {
+	if o.text == nil {
+		o.text = make(map[string]textMode, len(elements))
+	}
+	for _, name := range elements {
+		o.text[name] = mode
+	}
+}
+
+func (o *encodeOptions) rewrites() bool {
+	return o.canonical || len(o.text) != 0
+}
+
+func (o *encodeOptions) encode(w io.Writer, v *VAST) error {
+	enc := xml.NewEncoder(w)
+	if o.prefix != "" || o.indent != "" {
+		enc.Indent(o.prefix, o.indent)
+	}
+
+	start := xml.StartElement{Name: xml.Name{Space: o.namespace, Local: "VAST"}}
+	if err := enc.EncodeElement(v, start); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
@@ -0,0 +1,89 @@
+package vast
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decode", func() {
+	const params = "{\n\t\"placement\": \"pre-roll\",\n\t\"skippable\": true\n}"
+	const html = "<div class=\"ad\">\n\t<a href=\"http://example.com/\">\n\t\t<img src=\"http://example.com/banner.png\">\n\t</a>\n</div>"
+
+	It("should preserve whitespace", func() {
+		f, err := os.Open("testdata/vast_inline_multiline.xml")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+
+		v, err := Decode(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		in := v.Ads[0].InLine
+		Expect(in.Impressions[0].URI).To(Equal(URI("http://example.com/impression")))
+		Expect(string(in.Creatives[0].Linear.AdParameters.Parameters)).To(Equal(params))
+		Expect(string(in.Creatives[1].CompanionAds.Companions[0].HTMLResource.HTML)).To(Equal(html))
+	})
+
+	It("should round-trip through Encode", func() {
+		v, err := Decode(bytes.NewReader(mustReadFile("testdata/vast_inline_multiline.xml")))
+		Expect(err).NotTo(HaveOccurred())
+
+		buf := new(bytes.Buffer)
+		Expect(Encode(buf, v)).To(Succeed())
+		Expect(buf.String()).To(HavePrefix("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<VAST version=\"3.0\">"))
+		Expect(buf.String()).To(ContainSubstring("<AdParameters><![CDATA[" + params + "]]></AdParameters>"))
+		Expect(buf.String()).To(ContainSubstring("<HTMLResource><![CDATA[" + html + "]]></HTMLResource>"))
+
+		v2, err := Decode(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v2).To(Equal(v))
+	})
+
+})
+
+var _ = Describe("Encode", func() {
+
+	It("should declare the VAST 4 namespace", func() {
+		buf := new(bytes.Buffer)
+		Expect(Encode(buf, &VAST{Version: "4.1"})).To(Succeed())
+		Expect(buf.String()).To(Equal("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" + `<VAST xmlns="http://www.iab.com/VAST" version="4.1"></VAST>`))
+
+		buf.Reset()
+		Expect(Encode(buf, &VAST{Version: "3.0"})).To(Succeed())
+		Expect(buf.String()).To(HaveSuffix(`<VAST version="3.0"></VAST>`))
+	})
+
+	It("should support namespace overrides", func() {
+		buf := new(bytes.Buffer)
+		Expect(Encode(buf, &VAST{Version: "3.0"}, WithNamespace("http://example.com/vast"))).To(Succeed())
+		Expect(buf.String()).To(HaveSuffix(`<VAST xmlns="http://example.com/vast" version="3.0"></VAST>`))
+
+		buf.Reset()
+		Expect(Encode(buf, &VAST{Version: "4.0"}, WithNamespace(""))).To(Succeed())
+		Expect(buf.String()).To(HaveSuffix(`<VAST version="4.0"></VAST>`))
+	})
+
+	It("should apply options through MarshalXML", func() {
+		b, err := (&VAST{Version: "3.0"}).MarshalXML(WithNamespace("http://example.com/vast"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(HaveSuffix(`<VAST xmlns="http://example.com/vast" version="3.0"></VAST>`))
+
+		b, err = (&VAST{Version: "3.0"}).MarshalXML()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`<?xml version="1.0" encoding="UTF-8"?><VAST version="3.0"></VAST>`))
+	})
+
+	It("should decode namespaced documents", func() {
+		v, err := Decode(bytes.NewReader(mustReadFile("testdata/vast4_inline_linear.xml")))
+		Expect(err).NotTo(HaveOccurred())
+
+		buf := new(bytes.Buffer)
+		Expect(Encode(buf, v)).To(Succeed())
+		v2, err := Decode(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v2).To(Equal(v))
+	})
+
+})
@@ -0,0 +1,162 @@
+package vast
+
+import "strings"
+
+// Values of CompanionAds.Required
+const (
+	// The player must display all the companions
+	CompanionsRequiredAll = "all"
+	// The player must display at least one companion
+	CompanionsRequiredAny = "any"
+	// All the companions are optional
+	CompanionsRequiredNone = "none"
+)
+
+// ResourceType is a kind of companion resource
+type ResourceType string
+
+// Resource types
+const (
+	ResourceStatic ResourceType = "static"
+	ResourceIFrame ResourceType = "iframe"
+	ResourceHTML   ResourceType = "html"
+)
+
+// defaultResources is the resource preference of slots not specifying one
+var defaultResources = []ResourceType{ResourceStatic, ResourceIFrame, ResourceHTML}
+
+// CompanionSlot is a page or app area available for companion ads
+type CompanionSlot struct {
+	// The slot identifier, matched against Companion.AdSlotID
+	ID string
+	// Pixel dimensions of the slot, any companion size fits if zero
+	Width, Height int
+	// The resources the slot can render, by order of preference. If empty,
+	// static resources are preferred, then iframe and HTML resources.
+	Resources []ResourceType
+}
+
+// CompanionPlacement is a companion assigned to a slot
+type CompanionPlacement struct {
+	Slot      CompanionSlot
+	Companion Companion
+	// The companion resource to render
+	Resource ResourceType
+}
+
+// PlaceCompanions assigns the companions of a creative to the available
+// slots. Companions with an AdSlotID only go to the slot with that ID,
+// others go to a free slot of their dimensions that renders one of their
+// resources. As many companions as possible are placed, a companion only
+// gives up its first fitting slot when another companion needs it.
+// Placements are returned in document order.
+//
+// It returns ErrorCodeRequiredCompanion if the Required rule of the
+// companions cannot be honored, in which case the whole ad should be
+// rejected.
+func PlaceCompanions(ads *CompanionAds, slots []CompanionSlot) ([]CompanionPlacement, error) {
+	if ads == nil || len(ads.Companions) == 0 {
+		return nil, nil
+	}
+
+	owners := matchCompanions(ads.Companions, slots)
+	placed := make([]*CompanionPlacement, len(ads.Companions))
+	for j, i := range owners {
+		if i >= 0 {
+			res, _ := slots[j].resourceFor(ads.Companions[i])
+			placed[i] = &CompanionPlacement{Slot: slots[j], Companion: ads.Companions[i], Resource: res}
+		}
+	}
+
+	var placements []CompanionPlacement
+	for _, p := range placed {
+		if p != nil {
+			placements = append(placements, *p)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(ads.Required)) {
+	case CompanionsRequiredAll:
+		if len(placements) != len(ads.Companions) {
+			return nil, ErrorCodeRequiredCompanion
+		}
+	case CompanionsRequiredAny:
+		if len(placements) == 0 {
+			return nil, ErrorCodeRequiredCompanion
+		}
+	}
+	return placements, nil
+}
+
+// matchCompanions finds a maximum matching of companions to the slots they
+// fit in, using augmenting paths. It returns the index of the companion
+// placed in each slot, or -1 for free slots.
+func matchCompanions(companions []Companion, slots []CompanionSlot) []int {
+	fits := make([][]bool, len(companions))
+	for i, c := range companions {
+		fits[i] = make([]bool, len(slots))
+		for j, slot := range slots {
+			_, ok := slot.resourceFor(c)
+			fits[i][j] = ok && slot.fits(c)
+		}
+	}
+
+	owners := make([]int, len(slots))
+	for j := range owners {
+		owners[j] = -1
+	}
+
+	// augment places companion i, moving already placed companions to
+	// other slots if needed
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for j := range slots {
+			if visited[j] || !fits[i][j] {
+				continue
+			}
+			visited[j] = true
+			if owners[j] < 0 || augment(owners[j], visited) {
+				owners[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range companions {
+		augment(i, make([]bool, len(slots)))
+	}
+	return owners
+}
+
+func (s CompanionSlot) fits(c Companion) bool {
+	if c.AdSlotID != "" && c.AdSlotID != s.ID {
+		return false
+	}
+	if s.Width > 0 && c.Width > 0 && s.Width != c.Width {
+		return false
+	}
+	if s.Height > 0 && c.Height > 0 && s.Height != c.Height {
+		return false
+	}
+	return true
+}
+
+// resourceFor returns the preferred resource of a companion the slot
+// renders
+func (s CompanionSlot) resourceFor(c Companion) (ResourceType, bool) {
+	prefs := s.Resources
+	if len(prefs) == 0 {
+		prefs = defaultResources
+	}
+	for _, res := range prefs {
+		switch {
+		case res == ResourceStatic && c.StaticResource != nil:
+			return res, true
+		case res == ResourceIFrame && strings.TrimSpace(c.IFrameResource) != "":
+			return res, true
+		case res == ResourceHTML && c.HTMLResource != nil:
+			return res, true
+		}
+	}
+	return "", false
+}
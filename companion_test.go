@@ -0,0 +1,73 @@
+package vast
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlaceCompanions", func() {
+	banner := Companion{ID: "banner", Width: 300, Height: 250, StaticResource: &StaticResource{URI: "http://example.com/banner.png"}, IFrameResource: "http://example.com/banner.html"}
+	leader := Companion{ID: "leader", Width: 728, Height: 90, HTMLResource: &HTMLResource{HTML: []byte("<div/>")}}
+	slotted := Companion{ID: "slotted", Width: 300, Height: 250, AdSlotID: "side", IFrameResource: "http://example.com/side.html"}
+
+	slots := []CompanionSlot{
+		{ID: "top", Width: 728, Height: 90},
+		{ID: "side", Width: 300, Height: 250, Resources: []ResourceType{ResourceIFrame, ResourceStatic}},
+		{ID: "bottom", Width: 300, Height: 250, Resources: []ResourceType{ResourceStatic}},
+	}
+
+	It("should place companions", func() {
+		res, err := PlaceCompanions(&CompanionAds{Companions: []Companion{banner, leader, slotted}}, slots)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]CompanionPlacement{
+			{Slot: slots[2], Companion: banner, Resource: ResourceStatic},
+			{Slot: slots[0], Companion: leader, Resource: ResourceHTML},
+			{Slot: slots[1], Companion: slotted, Resource: ResourceIFrame},
+		}))
+	})
+
+	It("should find an assignment placing all companions", func() {
+		frame := Companion{ID: "frame", Width: 300, Height: 250, IFrameResource: "http://example.com/frame.html"}
+		res, err := PlaceCompanions(&CompanionAds{Required: "all", Companions: []Companion{banner, frame}}, slots[1:])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]CompanionPlacement{
+			{Slot: slots[2], Companion: banner, Resource: ResourceStatic},
+			{Slot: slots[1], Companion: frame, Resource: ResourceIFrame},
+		}))
+	})
+
+	It("should prefer slot resources", func() {
+		res, err := PlaceCompanions(&CompanionAds{Companions: []Companion{banner}}, slots[1:2])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Resource).To(Equal(ResourceIFrame))
+	})
+
+	DescribeTable("should honor the required rule",
+		func(required string, companions []Companion, placed int, fails bool) {
+			res, err := PlaceCompanions(&CompanionAds{Required: required, Companions: companions}, slots[:1])
+			if fails {
+				Expect(err).To(Equal(ErrorCodeRequiredCompanion))
+				Expect(ErrorCodeFor(err)).To(Equal(ErrorCodeRequiredCompanion))
+				Expect(res).To(BeNil())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res).To(HaveLen(placed))
+			}
+		},
+		Entry("all placed", "all", []Companion{leader}, 1, false),
+		Entry("all missing one", "all", []Companion{leader, banner}, 0, true),
+		Entry("any placed", "any", []Companion{banner, leader}, 1, false),
+		Entry("any missing", "any", []Companion{banner}, 0, true),
+		Entry("none", "none", []Companion{banner}, 0, false),
+		Entry("unspecified", "", []Companion{banner, leader}, 1, false),
+	)
+
+	It("should accept missing companions", func() {
+		res, err := PlaceCompanions(nil, slots)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeEmpty())
+	})
+
+})
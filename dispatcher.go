@@ -0,0 +1,269 @@
+package vast
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Dispatcher defaults
+const (
+	DefaultMaxPerHost = 4
+	DefaultMaxRetries = 2
+	DefaultBackoff    = 100 * time.Millisecond
+	DefaultTimeout    = 5 * time.Second
+)
+
+// DispatchResult is the outcome of requesting a tracking URI
+type DispatchResult struct {
+	URI URI
+	// The status code of the last response, zero if no response was received
+	StatusCode int
+	// The number of requests made
+	Attempts int
+	// The time spent sending requests across all attempts, excluding the
+	// waits for a host slot and the backoff delays
+	Elapsed time.Duration
+	// The error of the last attempt, if it failed
+	Err error
+}
+
+// Dispatcher requests tracking, impression and error URIs concurrently.
+// Requests to the same host are limited, failed requests are retried with an
+// exponential backoff. Responses with 2xx and 3xx status codes are considered
+// successful, redirects are not followed.
+//
+// A Dispatcher is safe for concurrent use and should be reused.
+type Dispatcher struct {
+	// The transport used to send requests. Uses http.DefaultTransport when nil.
+	Transport http.RoundTripper
+	// The maximum number of concurrent requests per host.
+	// Uses DefaultMaxPerHost when zero.
+	MaxPerHost int
+	// The maximum number of retries of a failed request, after the first attempt.
+	// Uses DefaultMaxRetries when zero, negative values disable retries.
+	MaxRetries int
+	// The delay before the first retry, doubled with each further retry.
+	// Uses DefaultBackoff when zero.
+	Backoff time.Duration
+	// The timeout of a single attempt. Uses DefaultTimeout when zero.
+	Timeout time.Duration
+	// Header is added to every request, e.g. to set the User-Agent.
+	Header http.Header
+	// OnResult, if set, is called with the outcome of every dispatched URI.
+	OnResult func(DispatchResult)
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots limits the concurrent requests to a host. It is removed from
+// the dispatcher once no request uses or waits for it.
+type hostSlots struct {
+	sem   chan struct{}
+	users int
+}
+
+// Dispatch requests all URIs and blocks until they are done. The header is
+// added to the requests, on top of the dispatcher's Header, which allows
+// forwarding the headers of the device on whose behalf the URIs are requested.
+// Results are returned in the order of uris.
+func (d *Dispatcher) Dispatch(ctx context.Context, uris []URI, header http.Header) []DispatchResult {
+	results := make([]DispatchResult, len(uris))
+
+	var wg sync.WaitGroup
+	for i, uri := range uris {
+		wg.Add(1)
+		go func(i int, uri URI) {
+			defer wg.Done()
+
+			results[i] = d.dispatch(ctx, uri, header)
+			if d.OnResult != nil {
+				d.OnResult(results[i])
+			}
+		}(i, uri)
+	}
+	wg.Wait()
+	return results
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, uri URI, header http.Header) DispatchResult {
+	res := DispatchResult{URI: uri}
+
+	u, err := url.Parse(uri.String())
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		res.Err = fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+		return res
+	}
+
+	backoff := d.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	for {
+		if err := d.acquire(ctx, u.Host); err != nil {
+			res.Err = err
+			return res
+		}
+		start := time.Now()
+		res.Attempts++
+		res.StatusCode, res.Err = d.send(ctx, u, header)
+		res.Elapsed += time.Since(start)
+		// the slot is not held while backing off
+		d.release(u.Host)
+
+		if !shouldRetry(res.StatusCode, res.Err) || res.Attempts > d.maxRetries() {
+			return res
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			res.Err = ctx.Err()
+			return res
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, u *url.URL, header http.Header) (int, error) {
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	// copy the values, as the transport may modify the request headers
+	for key, vals := range d.Header {
+		req.Header[key] = append([]string(nil), vals...)
+	}
+	for key, vals := range header {
+		req.Header[key] = append([]string(nil), vals...)
+	}
+
+	transport := d.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	// drain the body to allow connection reuse
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// acquire waits for a request slot to the host
+func (d *Dispatcher) acquire(ctx context.Context, host string) error {
+	d.mu.Lock()
+	if d.hosts == nil {
+		d.hosts = make(map[string]*hostSlots)
+	}
+	slots, ok := d.hosts[host]
+	if !ok {
+		n := d.MaxPerHost
+		if n <= 0 {
+			n = DefaultMaxPerHost
+		}
+		slots = &hostSlots{sem: make(chan struct{}, n)}
+		d.hosts[host] = slots
+	}
+	slots.users++
+	d.mu.Unlock()
+
+	select {
+	case slots.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		d.leave(host, slots)
+		return ctx.Err()
+	}
+}
+
+// release frees the request slot acquired for the host
+func (d *Dispatcher) release(host string) {
+	d.mu.Lock()
+	slots := d.hosts[host]
+	d.mu.Unlock()
+
+	<-slots.sem
+	d.leave(host, slots)
+}
+
+// leave removes the host once it is idle
+func (d *Dispatcher) leave(host string, slots *hostSlots) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	slots.users--
+	if slots.users == 0 {
+		delete(d.hosts, host)
+	}
+}
+
+func (d *Dispatcher) maxRetries() int {
+	switch {
+	case d.MaxRetries < 0:
+		return 0
+	case d.MaxRetries == 0:
+		return DefaultMaxRetries
+	}
+	return d.MaxRetries
+}
+
+// shouldRetry returns true for transport errors and server errors
+func shouldRetry(status int, err error) bool {
+	if err == nil {
+		return false
+	}
+	return status == 0 || status >= 500 || status == http.StatusTooManyRequests
+}
+
+// ForwardHeaders returns the headers to forward when requesting URIs on
+// behalf of the client of r: its User-Agent and Accept-Language, and an
+// X-Forwarded-For header extended with the client's IP address.
+func ForwardHeaders(r *http.Request) http.Header {
+	h := make(http.Header)
+	if ua := r.Header.Get("User-Agent"); ua != "" {
+		h.Set("User-Agent", ua)
+	}
+	if lang := r.Header.Get("Accept-Language"); lang != "" {
+		h.Set("Accept-Language", lang)
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	switch xff := r.Header.Get("X-Forwarded-For"); {
+	case xff != "" && ip != "":
+		h.Set("X-Forwarded-For", xff+", "+ip)
+	case xff != "":
+		h.Set("X-Forwarded-For", xff)
+	case ip != "":
+		h.Set("X-Forwarded-For", ip)
+	}
+	return h
+}
@@ -0,0 +1,136 @@
+package vast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dispatcher", func() {
+	var server *httptest.Server
+	var subject *Dispatcher
+	var flaky, active, maxActive int32
+	var mu sync.Mutex
+	var headers []http.Header
+
+	BeforeEach(func() {
+		flaky, active, maxActive = 0, 0, 0
+		headers = nil
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			headers = append(headers, r.Header)
+			mu.Unlock()
+		})
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		})
+		mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&flaky, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+		mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		})
+		server = httptest.NewServer(mux)
+
+		subject = &Dispatcher{
+			Transport: server.Client().Transport,
+			Backoff:   time.Millisecond,
+			Header:    http.Header{"User-Agent": {"dispatcher"}},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should dispatch URIs", func() {
+		var recorded []DispatchResult
+		subject.OnResult = func(r DispatchResult) {
+			mu.Lock()
+			recorded = append(recorded, r)
+			mu.Unlock()
+		}
+
+		res := subject.Dispatch(context.Background(), []URI{
+			URI(server.URL + "/ok"),
+			URI(server.URL + "/redirect"),
+			URI(server.URL + "/missing"),
+			"ftp://example.com/",
+		}, nil)
+		Expect(res).To(HaveLen(4))
+		Expect(res[0].StatusCode).To(Equal(200))
+		Expect(res[0].Err).NotTo(HaveOccurred())
+		Expect(res[1].StatusCode).To(Equal(302))
+		Expect(res[1].Err).NotTo(HaveOccurred())
+		Expect(res[2].StatusCode).To(Equal(404))
+		Expect(res[2].Attempts).To(Equal(1))
+		Expect(res[2].Err).To(MatchError("unexpected response status 404"))
+		Expect(res[3].Err).To(MatchError(`unsupported URI scheme "ftp"`))
+		Expect(recorded).To(HaveLen(4))
+	})
+
+	It("should retry server errors", func() {
+		res := subject.Dispatch(context.Background(), []URI{URI(server.URL + "/flaky")}, nil)
+		Expect(res[0].Err).NotTo(HaveOccurred())
+		Expect(res[0].Attempts).To(Equal(3))
+
+		atomic.StoreInt32(&flaky, 0)
+		subject.MaxRetries = -1
+		res = subject.Dispatch(context.Background(), []URI{URI(server.URL + "/flaky")}, nil)
+		Expect(res[0].StatusCode).To(Equal(503))
+		Expect(res[0].Attempts).To(Equal(1))
+	})
+
+	It("should limit concurrency per host", func() {
+		subject.MaxPerHost = 2
+		uris := make([]URI, 8)
+		for i := range uris {
+			uris[i] = URI(server.URL + "/slow")
+		}
+		res := subject.Dispatch(context.Background(), uris, nil)
+		Expect(atomic.LoadInt32(&maxActive)).To(Equal(int32(2)))
+		for _, r := range res {
+			// the wait for a slot is not part of the request
+			Expect(r.Elapsed).To(BeNumerically(">=", 20*time.Millisecond))
+			Expect(r.Elapsed).To(BeNumerically("<", 70*time.Millisecond))
+		}
+		Expect(subject.hosts).To(BeEmpty())
+	})
+
+	It("should forward client headers", func() {
+		client := httptest.NewRequest("GET", "http://example.com/", nil)
+		client.RemoteAddr = "10.0.0.2:4321"
+		client.Header.Set("User-Agent", "device")
+		client.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+		fwd := ForwardHeaders(client)
+		Expect(fwd).To(Equal(http.Header{
+			"User-Agent":      {"device"},
+			"X-Forwarded-For": {"10.0.0.1, 10.0.0.2"},
+		}))
+
+		subject.Dispatch(context.Background(), []URI{URI(server.URL + "/ok")}, fwd)
+		Expect(headers).To(HaveLen(1))
+		Expect(headers[0].Get("User-Agent")).To(Equal("device"))
+		Expect(headers[0].Get("X-Forwarded-For")).To(Equal("10.0.0.1, 10.0.0.2"))
+	})
+
+})
@@ -1,49 +1,112 @@
 package vast
 
 import (
+	"encoding/xml"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// ParseMode defines how strictly durations and offsets are parsed
+type ParseMode int
+
+// Parse modes
+const (
+	// ParseLenient accepts the VAST grammar along with common deviations:
+	// surrounding whitespace, single digit fields, fractions of any precision
+	// and plain numbers of seconds. It is used by UnmarshalText.
+	ParseLenient ParseMode = iota
+	// ParseStrict only accepts the VAST 4.3 grammar: HH:MM:SS or
+	// HH:MM:SS.mmm durations and n% offsets with n between 0 and 100.
+	ParseStrict
+)
+
+var (
+	strictDuration  = regexp.MustCompile(`^(\d{2,}):([0-5]\d):([0-5]\d)(?:\.(\d{3}))?$`)
+	lenientDuration = regexp.MustCompile(`^(\d+):(\d{1,2}):(\d{1,2})(?:\.(\d+))?$`)
+	lenientSeconds  = regexp.MustCompile(`^(\d+)(?:\.(\d+))?$`)
+)
+
 // Duration is a VAST duration expressed a hh:mm:ss
 type Duration time.Duration
 
-// MarshalText implements the encoding.TextMarshaler interface.
+// ParseDuration parses a duration in the given mode
+func ParseDuration(s string, mode ParseMode) (Duration, error) {
+	var h, m, sec, frac string
+	var plain bool
+	if mode == ParseStrict {
+		match := strictDuration.FindStringSubmatch(s)
+		if match == nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		h, m, sec, frac = match[1], match[2], match[3], match[4]
+	} else {
+		s := strings.TrimSpace(s)
+		if match := lenientDuration.FindStringSubmatch(s); match != nil {
+			h, m, sec, frac = match[1], match[2], match[3], match[4]
+		} else if match := lenientSeconds.FindStringSubmatch(s); match != nil {
+			h, m, sec, frac = "0", "0", match[1], match[2]
+			plain = true
+		} else {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+	}
+
+	var dur Duration
+	f := Duration(time.Second)
+	for i, field := range []string{sec, m, h} {
+		n, err := strconv.ParseInt(field, 10, 64)
+		// minutes and seconds are limited to 59, except for plain seconds
+		if err != nil || (i < 2 && n > 59 && !plain) {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		dur += Duration(n) * f
+		f *= 60
+	}
+	if frac != "" {
+		// nanoseconds are the highest precision, further digits are dropped
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		ns, _ := strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		dur += Duration(ns)
+	}
+	return dur, nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. Durations
+// with a sub-millisecond precision are written with as many digits as
+// needed.
 func (dur Duration) MarshalText() ([]byte, error) {
 	h := dur / Duration(time.Hour)
 	m := dur % Duration(time.Hour) / Duration(time.Minute)
 	s := dur % Duration(time.Minute) / Duration(time.Second)
-	ms := dur % Duration(time.Second) / Duration(time.Millisecond)
-	if ms == 0 {
+	ns := dur % Duration(time.Second)
+	switch {
+	case ns == 0:
 		return []byte(fmt.Sprintf("%02d:%02d:%02d", h, m, s)), nil
+	case ns%Duration(time.Millisecond) == 0:
+		return []byte(fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ns/Duration(time.Millisecond))), nil
 	}
-	return []byte(fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)), nil
+	frac := strings.TrimRight(fmt.Sprintf("%09d", ns), "0")
+	return []byte(fmt.Sprintf("%02d:%02d:%02d.%s", h, m, s, frac)), nil
 }
 
-// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// UnmarshalText implements the encoding.TextUnmarshaler interface. The
+// duration is parsed in lenient mode.
 func (dur *Duration) UnmarshalText(data []byte) (err error) {
-	parts := strings.SplitN(string(data), ":", 3)
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid duration: %s", data)
-	}
-	if i := strings.IndexByte(parts[2], '.'); i > 0 {
-		ms, err := strconv.ParseInt(parts[2][i+1:], 10, 32)
-		if err != nil || ms < 0 || ms > 999 {
-			return fmt.Errorf("invalid duration: %s", data)
-		}
-		parts[2] = parts[2][:i]
-		*dur += Duration(ms) * Duration(time.Millisecond)
-	}
-	f := Duration(time.Second)
-	for i := 2; i >= 0; i-- {
-		n, err := strconv.ParseInt(parts[i], 10, 32)
-		if err != nil || n < 0 || n > 59 {
-			return fmt.Errorf("invalid duration: %s", data)
-		}
-		*dur += Duration(n) * f
-		f *= 60
+	d, err := ParseDuration(string(data), ParseLenient)
+	if err != nil {
+		return err
 	}
+	*dur = d
 	return nil
 }
+
+// UnmarshalXMLAttr implements the xml.UnmarshalerAttr interface, naming the
+// attribute in errors
+func (dur *Duration) UnmarshalXMLAttr(attr xml.Attr) error {
+	return newAttrError(attr, dur.UnmarshalText([]byte(attr.Value)))
+}
@@ -0,0 +1,193 @@
+package vast
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode is a VAST error code, reported to the ad servers through the
+// [ERRORCODE] macro of the error URIs.
+type ErrorCode int
+
+// Error codes defined by VAST 2.0 to 4.3
+const (
+	ErrorCodeXMLParsing              ErrorCode = 100
+	ErrorCodeSchemaValidation        ErrorCode = 101
+	ErrorCodeVersionNotSupported     ErrorCode = 102
+	ErrorCodeTrafficking             ErrorCode = 200
+	ErrorCodeUnexpectedLinearity     ErrorCode = 201
+	ErrorCodeUnexpectedDuration      ErrorCode = 202
+	ErrorCodeUnexpectedSize          ErrorCode = 203
+	ErrorCodeCategoryRequired        ErrorCode = 204
+	ErrorCodeCategoryBlocked         ErrorCode = 205
+	ErrorCodeAdBreakShortened        ErrorCode = 206
+	ErrorCodeWrapper                 ErrorCode = 300
+	ErrorCodeWrapperTimeout          ErrorCode = 301
+	ErrorCodeWrapperLimit            ErrorCode = 302
+	ErrorCodeNoAdsAfterWrapper       ErrorCode = 303
+	ErrorCodeInLineDisplayTimeout    ErrorCode = 304
+	ErrorCodeLinear                  ErrorCode = 400
+	ErrorCodeFileNotFound            ErrorCode = 401
+	ErrorCodeMediaFileTimeout        ErrorCode = 402
+	ErrorCodeNoSupportedMedia        ErrorCode = 403
+	ErrorCodeMediaFileDisplay        ErrorCode = 405
+	ErrorCodeMezzanineRequired       ErrorCode = 406
+	ErrorCodeMezzanineDownloading    ErrorCode = 407
+	ErrorCodeConditionalAdRejected   ErrorCode = 408
+	ErrorCodeInteractiveNotExecuted  ErrorCode = 409
+	ErrorCodeVerificationNotExecuted ErrorCode = 410
+	ErrorCodeMezzanineNotInSpec      ErrorCode = 411
+	ErrorCodeNonLinear               ErrorCode = 500
+	ErrorCodeNonLinearDimensions     ErrorCode = 501
+	ErrorCodeNonLinearFetch          ErrorCode = 502
+	ErrorCodeNoSupportedNonLinear    ErrorCode = 503
+	ErrorCodeCompanion               ErrorCode = 600
+	ErrorCodeCompanionDimensions     ErrorCode = 601
+	ErrorCodeRequiredCompanion       ErrorCode = 602
+	ErrorCodeCompanionFetch          ErrorCode = 603
+	ErrorCodeNoSupportedCompanion    ErrorCode = 604
+	ErrorCodeUndefined               ErrorCode = 900
+	ErrorCodeVPAID                   ErrorCode = 901
+	ErrorCodeInteractiveCreativeFile ErrorCode = 902
+)
+
+var errorCodeDescriptions = map[ErrorCode]string{
+	ErrorCodeXMLParsing:              "XML parsing error",
+	ErrorCodeSchemaValidation:        "VAST schema validation error",
+	ErrorCodeVersionNotSupported:     "VAST version of response not supported",
+	ErrorCodeTrafficking:             "Trafficking error, the player received an ad type that it was not expecting or cannot display",
+	ErrorCodeUnexpectedLinearity:     "Player expecting different linearity",
+	ErrorCodeUnexpectedDuration:      "Player expecting different duration",
+	ErrorCodeUnexpectedSize:          "Player expecting different size",
+	ErrorCodeCategoryRequired:        "Ad category was required but not provided",
+	ErrorCodeCategoryBlocked:         "InLine category violates wrapper BlockedAdCategories",
+	ErrorCodeAdBreakShortened:        "Ad break shortened, ad was not served",
+	ErrorCodeWrapper:                 "General wrapper error",
+	ErrorCodeWrapperTimeout:          "Timeout of VAST URI provided in wrapper element",
+	ErrorCodeWrapperLimit:            "Wrapper limit reached",
+	ErrorCodeNoAdsAfterWrapper:       "No VAST response after one or more wrappers",
+	ErrorCodeInLineDisplayTimeout:    "InLine response returned ad unit that failed to result in ad display within defined time limit",
+	ErrorCodeLinear:                  "General linear error",
+	ErrorCodeFileNotFound:            "File not found, unable to find linear/MediaFile from URI",
+	ErrorCodeMediaFileTimeout:        "Timeout of MediaFile URI",
+	ErrorCodeNoSupportedMedia:        "Couldn't find MediaFile that is supported by this video player",
+	ErrorCodeMediaFileDisplay:        "Problem displaying MediaFile",
+	ErrorCodeMezzanineRequired:       "Mezzanine was required but not provided",
+	ErrorCodeMezzanineDownloading:    "Mezzanine is in the process of being downloaded for the first time",
+	ErrorCodeConditionalAdRejected:   "Conditional ad rejected",
+	ErrorCodeInteractiveNotExecuted:  "Interactive unit in the InteractiveCreativeFile node was not executed",
+	ErrorCodeVerificationNotExecuted: "Verification unit in the Verification node was not executed",
+	ErrorCodeMezzanineNotInSpec:      "Mezzanine was provided as required, but file did not meet required specification",
+	ErrorCodeNonLinear:               "General NonLinearAds error",
+	ErrorCodeNonLinearDimensions:     "Unable to display NonLinear ad because creative dimensions do not align with creative display area",
+	ErrorCodeNonLinearFetch:          "Unable to fetch NonLinearAds/NonLinear resource",
+	ErrorCodeNoSupportedNonLinear:    "Couldn't find NonLinear resource with supported type",
+	ErrorCodeCompanion:               "General CompanionAds error",
+	ErrorCodeCompanionDimensions:     "Unable to display companion because creative dimensions do not fit within companion display area",
+	ErrorCodeRequiredCompanion:       "Unable to display required companion",
+	ErrorCodeCompanionFetch:          "Unable to fetch CompanionAds/Companion resource",
+	ErrorCodeNoSupportedCompanion:    "Couldn't find companion resource with supported type",
+	ErrorCodeUndefined:               "Undefined error",
+	ErrorCodeVPAID:                   "General VPAID error",
+	ErrorCodeInteractiveCreativeFile: "General InteractiveCreativeFile error",
+}
+
+// Description returns the description of the error code, as given by the spec
+func (c ErrorCode) Description() string {
+	if desc, ok := errorCodeDescriptions[c]; ok {
+		return desc
+	}
+	return "Unknown error"
+}
+
+// Error implements the error interface
+func (c ErrorCode) Error() string {
+	return fmt.Sprintf("vast error %d: %s", int(c), c.Description())
+}
+
+// ErrorCodeFor returns the error code matching err. It recognizes error codes,
+// the errors returned by Resolver, parse errors and XML syntax errors, and
+// falls back to ErrorCodeUndefined.
+func ErrorCodeFor(err error) ErrorCode {
+	var code ErrorCode
+	var parseErr *ParseError
+	var syntaxErr *xml.SyntaxError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &code):
+		return code
+	case errors.Is(err, ErrWrapperLimit):
+		return ErrorCodeWrapperLimit
+	case errors.Is(err, ErrNoAdsAfterWrapper):
+		return ErrorCodeNoAdsAfterWrapper
+	case errors.Is(err, ErrAdditionalWrapper):
+		return ErrorCodeWrapper
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeWrapperTimeout
+	case errors.As(err, &parseErr):
+		return parseErr.Code
+	case errors.As(err, &syntaxErr):
+		return ErrorCodeXMLParsing
+	}
+	return ErrorCodeUndefined
+}
+
+// ErrorURIs returns the error URIs of the ad, with the error code and the
+// other macros of m expanded. The macros may be nil.
+func (ad *Ad) ErrorURIs(code ErrorCode, m *Macros) []URI {
+	var errs []Error
+	switch {
+	case ad.InLine != nil:
+		errs = ad.InLine.Error
+	case ad.Wrapper != nil:
+		errs = ad.Wrapper.Error
+	}
+
+	uris := make([]URI, 0, len(errs))
+	for _, e := range errs {
+		uris = append(uris, e.URI)
+	}
+	return ExpandURIs(uris, withErrorCode(m, code))
+}
+
+// ErrorURIs returns the error URIs of the whole wrapper chain and the
+// resolved ad, outermost first, with the error code and the other macros of m
+// expanded. The macros may be nil.
+func (r *ResolvedAd) ErrorURIs(code ErrorCode, m *Macros) []URI {
+	var uris []URI
+	for _, w := range r.Wrappers {
+		for _, e := range w.Error {
+			uris = append(uris, e.URI)
+		}
+	}
+	if r.Ad != nil && r.Ad.InLine != nil {
+		for _, e := range r.Ad.InLine.Error {
+			uris = append(uris, e.URI)
+		}
+	}
+	return ExpandURIs(uris, withErrorCode(m, code))
+}
+
+// ErrorURIs returns the root level error URIs of the document, which
+// should be requested when the response contains no ads, with the error code
+// and the other macros of m expanded. The macros may be nil.
+func (v *VAST) ErrorURIs(code ErrorCode, m *Macros) []URI {
+	uris := make([]URI, 0, len(v.Errors))
+	for _, e := range v.Errors {
+		uris = append(uris, URI(strings.TrimSpace(e)))
+	}
+	return ExpandURIs(uris, withErrorCode(m, code))
+}
+
+func withErrorCode(m *Macros, code ErrorCode) *Macros {
+	var res Macros
+	if m != nil {
+		res = *m
+	}
+	res.ErrorCode = code
+	return &res
+}
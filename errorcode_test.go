@@ -0,0 +1,57 @@
+package vast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrorCode", func() {
+
+	It("should describe codes", func() {
+		Expect(ErrorCodeNoSupportedMedia.Description()).To(Equal("Couldn't find MediaFile that is supported by this video player"))
+		Expect(ErrorCode(999).Description()).To(Equal("Unknown error"))
+		Expect(ErrorCodeWrapperLimit.Error()).To(Equal("vast error 302: Wrapper limit reached"))
+	})
+
+	DescribeTable("for errors",
+		func(err error, exp ErrorCode) {
+			Expect(ErrorCodeFor(err)).To(Equal(exp))
+		},
+		Entry("nil", nil, ErrorCode(0)),
+		Entry("code", ErrorCodeFileNotFound, ErrorCodeFileNotFound),
+		Entry("wrapped code", fmt.Errorf("failed: %w", ErrorCodeFileNotFound), ErrorCodeFileNotFound),
+		Entry("wrapper limit", ErrWrapperLimit, ErrorCodeWrapperLimit),
+		Entry("no ads", ErrNoAdsAfterWrapper, ErrorCodeNoAdsAfterWrapper),
+		Entry("additional wrapper", ErrAdditionalWrapper, ErrorCodeWrapper),
+		Entry("timeout", context.DeadlineExceeded, ErrorCodeWrapperTimeout),
+		Entry("other", errors.New("boom"), ErrorCodeUndefined),
+	)
+
+	It("should expand error URIs across wrapper chains", func() {
+		ra := &ResolvedAd{
+			Wrappers: []*Wrapper{
+				{Error: []Error{{URI: "http://outer/error?code=[ERRORCODE]"}}},
+				{Error: []Error{{URI: "http://inner/error"}}},
+			},
+			Ad: &Ad{InLine: &InLine{
+				Error: []Error{{URI: "http://inline/error?c=[ERRORCODE]&again=[ERRORCODE]"}},
+			}},
+		}
+		Expect(ra.ErrorURIs(ErrorCodeNoSupportedMedia, nil)).To(Equal([]URI{
+			"http://outer/error?code=403",
+			"http://inner/error",
+			"http://inline/error?c=403&again=403",
+		}))
+	})
+
+	It("should expand root error URIs", func() {
+		v := &VAST{Errors: []string{" http://example.com/noad?e=[ERRORCODE]&cb=[CACHEBUSTING] "}}
+		Expect(v.ErrorURIs(ErrorCodeNoAdsAfterWrapper, &Macros{CacheBusting: "123"})).To(Equal([]URI{"http://example.com/noad?e=303&cb=123"}))
+	})
+
+})
@@ -0,0 +1,110 @@
+package vast
+
+import "strings"
+
+// EventType is the name of a tracking event. The original value is kept as
+// given in the document, use Normalize to compare it to the known events
+// regardless of case.
+type EventType string
+
+// Tracking events defined by VAST 2.0 to 4.3
+const (
+	// Linear, nonlinear and companion events
+	EventCreativeView EventType = "creativeView"
+
+	// Linear events
+	EventStart              EventType = "start"
+	EventFirstQuartile      EventType = "firstQuartile"
+	EventMidpoint           EventType = "midpoint"
+	EventThirdQuartile      EventType = "thirdQuartile"
+	EventComplete           EventType = "complete"
+	EventProgress           EventType = "progress"
+	EventSkip               EventType = "skip"
+	EventOtherAdInteraction EventType = "otherAdInteraction"
+	EventCloseLinear        EventType = "closeLinear"
+	EventLoaded             EventType = "loaded"
+	EventInteractiveStart   EventType = "interactiveStart"
+
+	// Player state events
+	EventMute           EventType = "mute"
+	EventUnmute         EventType = "unmute"
+	EventPause          EventType = "pause"
+	EventResume         EventType = "resume"
+	EventRewind         EventType = "rewind"
+	EventFullscreen     EventType = "fullscreen"
+	EventExitFullscreen EventType = "exitFullscreen"
+	EventPlayerExpand   EventType = "playerExpand"
+	EventPlayerCollapse EventType = "playerCollapse"
+
+	// Nonlinear events
+	EventExpand                 EventType = "expand"
+	EventCollapse               EventType = "collapse"
+	EventAdExpand               EventType = "adExpand"
+	EventAdCollapse             EventType = "adCollapse"
+	EventMinimize               EventType = "minimize"
+	EventClose                  EventType = "close"
+	EventAcceptInvitation       EventType = "acceptInvitation"
+	EventAcceptInvitationLinear EventType = "acceptInvitationLinear"
+	EventOverlayViewDuration    EventType = "overlayViewDuration"
+	EventTimeSpentViewing       EventType = "timeSpentViewing"
+
+	// Miscellaneous events
+	EventNotUsed                 EventType = "notUsed"
+	EventVerificationNotExecuted EventType = "verificationNotExecuted"
+)
+
+var knownEvents = make(map[string]EventType)
+
+func init() {
+	for _, e := range []EventType{
+		EventCreativeView,
+		EventStart, EventFirstQuartile, EventMidpoint, EventThirdQuartile, EventComplete,
+		EventProgress, EventSkip, EventOtherAdInteraction, EventCloseLinear, EventLoaded,
+		EventInteractiveStart,
+		EventMute, EventUnmute, EventPause, EventResume, EventRewind, EventFullscreen,
+		EventExitFullscreen, EventPlayerExpand, EventPlayerCollapse,
+		EventExpand, EventCollapse, EventAdExpand, EventAdCollapse, EventMinimize, EventClose,
+		EventAcceptInvitation, EventAcceptInvitationLinear, EventOverlayViewDuration,
+		EventTimeSpentViewing,
+		EventNotUsed, EventVerificationNotExecuted,
+	} {
+		knownEvents[strings.ToLower(string(e))] = e
+	}
+}
+
+// Normalize returns the known event matching e regardless of case and
+// surrounding whitespace, or e itself when unknown.
+func (e EventType) Normalize() EventType {
+	if known, ok := knownEvents[strings.ToLower(strings.TrimSpace(string(e)))]; ok {
+		return known
+	}
+	return e
+}
+
+// IsKnown returns true if e matches one of the events defined by the spec
+func (e EventType) IsKnown() bool {
+	_, ok := knownEvents[strings.ToLower(strings.TrimSpace(string(e)))]
+	return ok
+}
+
+// TrackersFor returns the URIs of the tracking events matching the event type
+func (l *Linear) TrackersFor(event EventType) []URI {
+	return trackersFor(l.TrackingEvents, event)
+}
+
+// TrackersFor returns the URIs of the tracking events matching the event type
+func (n *NonLinearAds) TrackersFor(event EventType) []URI {
+	return trackersFor(n.TrackingEvents, event)
+}
+
+func trackersFor(events []Tracking, event EventType) []URI {
+	event = event.Normalize()
+
+	var uris []URI
+	for _, t := range events {
+		if t.Event.Normalize() == event {
+			uris = append(uris, t.URI)
+		}
+	}
+	return uris
+}
@@ -0,0 +1,49 @@
+package vast
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventType", func() {
+
+	DescribeTable("normalize",
+		func(e EventType, exp EventType, known bool) {
+			Expect(e.Normalize()).To(Equal(exp))
+			Expect(e.IsKnown()).To(Equal(known))
+		},
+		Entry("canonical", EventType("firstQuartile"), EventFirstQuartile, true),
+		Entry("lower case", EventType("firstquartile"), EventFirstQuartile, true),
+		Entry("upper case", EventType("EXITFULLSCREEN"), EventExitFullscreen, true),
+		Entry("whitespace", EventType(" start "), EventStart, true),
+		Entry("unknown", EventType("firstQuart"), EventType("firstQuart"), false),
+	)
+
+	It("should keep the original value on marshal", func() {
+		v := &VAST{Version: "3.0", Ads: []Ad{{InLine: &InLine{Creatives: []Creative{{Linear: &Linear{
+			TrackingEvents: []Tracking{{Event: "firstquartile", URI: "http://example.com/q1"}},
+		}}}}}}}
+		b, err := v.MarshalXML()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`<Tracking event="firstquartile">`))
+	})
+
+	It("should look up linear trackers", func() {
+		l := &Linear{TrackingEvents: []Tracking{
+			{Event: "start", URI: "http://example.com/start"},
+			{Event: "firstquartile", URI: "http://example.com/q1"},
+			{Event: "firstQuartile", URI: "http://example.com/q1b"},
+		}}
+		Expect(l.TrackersFor(EventFirstQuartile)).To(Equal([]URI{"http://example.com/q1", "http://example.com/q1b"}))
+		Expect(l.TrackersFor(EventComplete)).To(BeEmpty())
+	})
+
+	It("should look up nonlinear trackers", func() {
+		n := &NonLinearAds{TrackingEvents: []Tracking{
+			{Event: "Expand", URI: "http://example.com/expand"},
+		}}
+		Expect(n.TrackersFor(EventExpand)).To(Equal([]URI{"http://example.com/expand"}))
+	})
+
+})
@@ -0,0 +1,257 @@
+package vast
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrBreakUnderfilled is returned when a break cannot be filled with its
+// minimum number of ads
+var ErrBreakUnderfilled = errors.New("not enough ads to fill the break")
+
+// Break is an ad break of fixed length to fill with linear ads, as done by
+// server-side ad insertion
+type Break struct {
+	// The duration of the break, which the ads can't exceed
+	Duration time.Duration
+	// The minimum number of ads of the break
+	MinAds int
+	// The maximum number of ads of the break, unlimited if zero
+	MaxAds int
+	// The minimum number of other ads between two ads of the same
+	// advertiser. If zero, the same advertiser may play back to back.
+	AdvertiserSeparation int
+}
+
+// Fill picks InLine ads with a linear creative from resolved VAST documents
+// and returns a new VAST pod made of them, with sequences starting at 1.
+//
+// Ads are picked greedily by priority, in the order of the documents and of
+// their ads: an ad is skipped if it exceeds the remaining duration, or if
+// one of its creatives, identified by AdID, UniversalAdId or ID within the
+// ad system serving it, was already picked. An ad too close to an ad of the
+// same advertiser is deferred until enough other ads are picked. When fewer
+// than MinAds ads are picked that way, the ads are searched for a pod of
+// MinAds ads by backtracking, which is then completed greedily.
+//
+// The ads of the pod share their InLine elements with the documents. It
+// returns ErrBreakUnderfilled if fewer than MinAds ads fit.
+func (b Break) Fill(docs ...*VAST) (*VAST, error) {
+	pod := &VAST{}
+	var candidates []*breakAd
+	for _, v := range docs {
+		if v == nil {
+			continue
+		}
+		if pod.Version == "" {
+			pod.Version = v.Version
+		}
+		for _, ad := range v.Ads {
+			if ad.InLine != nil {
+				candidates = append(candidates, &breakAd{ad: ad, duration: adDuration(&ad), keys: creativeKeys(ad.InLine)})
+			}
+		}
+	}
+
+	p := &breakPlan{b: b, seen: map[string]int{}}
+	used := make([]bool, len(candidates))
+	p.fill(candidates, used)
+	if len(p.ads) < b.MinAds {
+		p = &breakPlan{b: b, seen: map[string]int{}}
+		used = make([]bool, len(candidates))
+		steps := maxBreakSearchSteps
+		if !p.search(candidates, used, 0, &steps) {
+			return nil, ErrBreakUnderfilled
+		}
+		p.fill(candidates, used)
+	}
+
+	for i, a := range p.ads {
+		ad := a.ad
+		ad.Sequence = i + 1
+		pod.Ads = append(pod.Ads, ad)
+	}
+	return pod, nil
+}
+
+// maxBreakSearchSteps bounds the number of ads tried while backtracking
+const maxBreakSearchSteps = 10000
+
+// breakAd is an ad to place in a break
+type breakAd struct {
+	ad       Ad
+	duration time.Duration
+	keys     []string
+}
+
+// breakPlan is the list of ads picked for a break
+type breakPlan struct {
+	b     Break
+	ads   []*breakAd
+	total time.Duration
+	seen  map[string]int
+}
+
+func (p *breakPlan) full() bool {
+	return p.b.MaxAds > 0 && len(p.ads) >= p.b.MaxAds
+}
+
+// fits returns whether the ad fits the remaining duration and repeats none
+// of the creatives picked
+func (p *breakPlan) fits(a *breakAd) bool {
+	if a.duration <= 0 || p.total+a.duration > p.b.Duration {
+		return false
+	}
+	for _, key := range a.keys {
+		if p.seen[key] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *breakPlan) push(a *breakAd) {
+	for _, key := range a.keys {
+		p.seen[key]++
+	}
+	p.ads = append(p.ads, a)
+	p.total += a.duration
+}
+
+func (p *breakPlan) pop() {
+	a := p.ads[len(p.ads)-1]
+	for _, key := range a.keys {
+		p.seen[key]--
+	}
+	p.ads = p.ads[:len(p.ads)-1]
+	p.total -= a.duration
+}
+
+// fill picks the unused candidates greedily, deferring the ads which are
+// too close to an ad of the same advertiser
+func (p *breakPlan) fill(candidates []*breakAd, used []bool) {
+	var pending []int
+	// add picks the candidate if it fits, returns false if it must be
+	// deferred
+	add := func(i int) bool {
+		a := candidates[i]
+		switch {
+		case !p.fits(a):
+			return true
+		case !p.b.separated(p.ads, a.ad):
+			return false
+		}
+		used[i] = true
+		p.push(a)
+		return true
+	}
+
+	for i := range candidates {
+		if p.full() {
+			break
+		}
+		if used[i] {
+			continue
+		}
+		if !add(i) {
+			pending = append(pending, i)
+			continue
+		}
+		// the pod changed, deferred ads may fit now
+		for j := 0; j < len(pending) && !p.full(); j++ {
+			if add(pending[j]) {
+				pending = append(pending[:j], pending[j+1:]...)
+				j = -1
+			}
+		}
+	}
+}
+
+// search looks for MinAds ads fitting the break by backtracking, trying the
+// candidates by priority from the given one. Without advertiser separation
+// the order of the ads doesn't matter, so only later candidates are tried.
+func (p *breakPlan) search(candidates []*breakAd, used []bool, from int, steps *int) bool {
+	if len(p.ads) >= p.b.MinAds {
+		return true
+	}
+	if p.full() {
+		return false
+	}
+	if p.b.AdvertiserSeparation > 0 {
+		from = 0
+	}
+	for i := from; i < len(candidates) && *steps > 0; i++ {
+		a := candidates[i]
+		if used[i] || !p.fits(a) || !p.b.separated(p.ads, a.ad) {
+			continue
+		}
+		*steps--
+		used[i] = true
+		p.push(a)
+		if p.search(candidates, used, i+1, steps) {
+			return true
+		}
+		p.pop()
+		used[i] = false
+	}
+	return false
+}
+
+// separated returns whether the ad can follow the ads without breaking the
+// advertiser separation
+func (b Break) separated(ads []*breakAd, ad Ad) bool {
+	key := advertiserKey(ad.InLine)
+	if b.AdvertiserSeparation <= 0 || key == "" {
+		return true
+	}
+	for i := len(ads) - 1; i >= 0 && i >= len(ads)-b.AdvertiserSeparation; i-- {
+		if advertiserKey(ads[i].ad.InLine) == key {
+			return false
+		}
+	}
+	return true
+}
+
+func advertiserKey(in *InLine) string {
+	switch {
+	case in == nil || in.Advertiser == nil:
+		return ""
+	case in.Advertiser.ID != "":
+		return "id:" + in.Advertiser.ID
+	}
+	if name := strings.ToLower(strings.TrimSpace(in.Advertiser.Name)); name != "" {
+		return "name:" + name
+	}
+	return ""
+}
+
+// creativeKeys returns the identifiers of the creatives of an ad
+func creativeKeys(in *InLine) []string {
+	// creative ids are only unique within an ad server
+	var system string
+	if in.AdSystem != nil {
+		system = strings.TrimSpace(in.AdSystem.Name)
+	}
+
+	var keys []string
+	for i := range in.Creatives {
+		c := &in.Creatives[i]
+		if id := c.adID(); id != "" {
+			keys = append(keys, "adid:"+id)
+		}
+		if c.ID != "" {
+			keys = append(keys, "id:"+system+":"+c.ID)
+		}
+		for _, u := range c.UniversalAdIDs {
+			id := strings.TrimSpace(u.ID)
+			if id == "" {
+				id = u.IDValue
+			}
+			if id != "" && !strings.EqualFold(id, "unknown") {
+				keys = append(keys, "universal:"+u.IDRegistry+":"+id)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,120 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Break", func() {
+	ad := func(id, advertiser string, d time.Duration, creative Creative) Ad {
+		creative.Linear = &Linear{Duration: durationPtr(d)}
+		return Ad{ID: id, Sequence: 7, InLine: &InLine{
+			Advertiser: &Advertiser{Name: advertiser},
+			Creatives:  []Creative{creative},
+		}}
+	}
+	ids := func(v *VAST) []string {
+		var ids []string
+		for i, ad := range v.Ads {
+			Expect(ad.Sequence).To(Equal(i + 1))
+			ids = append(ids, ad.ID)
+		}
+		return ids
+	}
+
+	docs := []*VAST{
+		{Version: "4.1", Ads: []Ad{
+			ad("a1", "Acme", 30*time.Second, Creative{AdID: "c1"}),
+			ad("a2", "acme", 15*time.Second, Creative{AdID: "c2"}),
+			{ID: "wrapper", Wrapper: &Wrapper{}},
+		}},
+		{Version: "3.0", Ads: []Ad{
+			ad("dup", "Other", 15*time.Second, Creative{AdID: "c1"}),
+			ad("b1", "Brand", 45*time.Second, Creative{UniversalAdIDs: []UniversalAdID{{IDRegistry: "ad-id.org", ID: "U1"}}}),
+			ad("b2", "Brand", 15*time.Second, Creative{UniversalAdIDs: []UniversalAdID{{IDRegistry: "ad-id.org", ID: "U1"}}}),
+			ad("c1", "", 20*time.Second, Creative{ID: "x"}),
+			ad("c2", "", 10*time.Second, Creative{ID: "y"}),
+		}},
+	}
+
+	It("should fill breaks", func() {
+		v, err := Break{Duration: 90 * time.Second}.Fill(docs...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Version).To(Equal("4.1"))
+		Expect(ids(v)).To(Equal([]string{"a1", "a2", "b1"}))
+
+		// the documents are untouched
+		Expect(docs[0].Ads[0].Sequence).To(Equal(7))
+	})
+
+	It("should limit the number of ads", func() {
+		v, err := Break{Duration: 120 * time.Second, MaxAds: 2}.Fill(docs...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"a1", "a2"}))
+
+		v, err = Break{Duration: 40 * time.Second, MinAds: 3}.Fill(docs...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"a2", "dup", "c2"}))
+
+		_, err = Break{Duration: 40 * time.Second, MinAds: 4}.Fill(docs...)
+		Expect(err).To(Equal(ErrBreakUnderfilled))
+	})
+
+	It("should search for a fill when the greedy pick is underfilled", func() {
+		candidates := &VAST{Ads: []Ad{
+			ad("long", "", 60*time.Second, Creative{AdID: "l"}),
+			ad("short1", "", 45*time.Second, Creative{AdID: "s1"}),
+			ad("short2", "", 45*time.Second, Creative{AdID: "s2"}),
+		}}
+		v, err := Break{Duration: 90 * time.Second}.Fill(candidates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"long"}))
+
+		v, err = Break{Duration: 90 * time.Second, MinAds: 2}.Fill(candidates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"short1", "short2"}))
+
+		_, err = Break{Duration: 90 * time.Second, MinAds: 3}.Fill(candidates)
+		Expect(err).To(Equal(ErrBreakUnderfilled))
+	})
+
+	It("should search for a fill completed greedily", func() {
+		v, err := Break{Duration: 60 * time.Second, MinAds: 3, AdvertiserSeparation: 1}.Fill(&VAST{Ads: []Ad{
+			ad("long", "Acme", 50*time.Second, Creative{AdID: "l"}),
+			ad("a", "Acme", 20*time.Second, Creative{AdID: "a"}),
+			ad("b", "Brand", 20*time.Second, Creative{AdID: "b"}),
+			ad("c", "Acme", 15*time.Second, Creative{AdID: "c"}),
+			ad("d", "Other", 5*time.Second, Creative{AdID: "d"}),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"a", "b", "c", "d"}))
+	})
+
+	It("should separate advertisers", func() {
+		v, err := Break{Duration: 120 * time.Second, AdvertiserSeparation: 1}.Fill(docs...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"a1", "b1", "a2", "c1", "c2"}))
+
+		v, err = Break{Duration: 120 * time.Second, AdvertiserSeparation: 2}.Fill(docs...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"a1", "b1", "c1", "a2", "c2"}))
+	})
+
+	It("should scope creative ids to their ad system", func() {
+		served := func(id, system string) Ad {
+			a := ad(id, "", 10*time.Second, Creative{ID: "x"})
+			a.InLine.AdSystem = &AdSystem{Name: system}
+			return a
+		}
+		v, err := Break{Duration: 60 * time.Second}.Fill(&VAST{Ads: []Ad{
+			served("s1", "one"),
+			served("s2", "two"),
+			served("s3", "one"),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(v)).To(Equal([]string{"s1", "s2"}))
+	})
+
+})
@@ -0,0 +1,334 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// emptyContainers are the elements omitted by canonical output when they
+// have neither attributes nor content. encoding/xml writes the parents of
+// "a>b" tagged fields even for empty slices.
+var emptyContainers = map[string]bool{
+	"AdVerifications":    true,
+	"Creatives":          true,
+	"CreativeExtensions": true,
+	"Extensions":         true,
+	"IconClicks":         true,
+	"MediaFiles":         true,
+	"TrackingEvents":     true,
+	"VideoClicks":        true,
+}
+
+// canonicalCopy returns a deep copy of v with sorted extensions and creative
+// extensions
+func canonicalCopy(v *VAST) *VAST {
+	c := copyVAST(v)
+	sortExtensions(reflect.ValueOf(c))
+	return c
+}
+
+var (
+	extensionsType         = reflect.TypeOf(Extensions{})
+	creativeExtensionsType = reflect.TypeOf(CreativeExtensions{})
+)
+
+// sortExtensions sorts in place the extensions found in v
+func sortExtensions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			sortExtensions(v.Elem())
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < v.Len(); i++ {
+				sortExtensions(v.Index(i))
+			}
+		}
+	case reflect.Struct:
+		switch v.Type() {
+		case extensionsType:
+			sortExtensionSlice(v.Addr().Interface().(*Extensions).Extensions)
+			return
+		case creativeExtensionsType:
+			sortExtensionSlice(v.Addr().Interface().(*CreativeExtensions).Extensions)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				sortExtensions(v.Field(i))
+			}
+		}
+	}
+}
+
+func sortExtensionSlice(exts []Extension) {
+	sort.SliceStable(exts, func(i, j int) bool {
+		if exts[i].Type != exts[j].Type {
+			return exts[i].Type < exts[j].Type
+		}
+		return bytes.Compare(exts[i].Data, exts[j].Data) < 0
+	})
+}
+
+type pieceKind int
+
+const (
+	pieceText pieceKind = iota
+	pieceCDATA
+	pieceStart
+	pieceEnd
+	pieceOther // comments, processing instructions and self-closing elements
+)
+
+// piece is a raw token of an XML document
+type piece struct {
+	kind pieceKind
+	raw  []byte
+	// The element name of start and end pieces
+	name string
+	// Whether a start piece has attributes
+	attrs bool
+}
+
+// rewrite applies the canonical and text options to XML produced by
+// encoding/xml. It works on the raw bytes, so that CDATA sections and inner
+// XML are kept as they are. Only the elements of the VAST model are
+// rewritten, vendor content such as the inner XML of extensions is not.
+func rewrite(b []byte, o *encodeOptions) []byte {
+	type frame struct {
+		name   string
+		vendor bool
+	}
+
+	var out []piece
+	var stack []frame
+	for _, p := range scanPieces(b) {
+		switch p.kind {
+		case pieceStart:
+			f := frame{name: p.name, vendor: !modelledElements()[p.name]}
+			if n := len(stack); n != 0 && (stack[n-1].vendor || rawElements()[stack[n-1].name]) {
+				f.vendor = true
+			}
+			stack = append(stack, f)
+		case pieceEnd:
+			var vendor bool
+			if n := len(stack); n != 0 {
+				vendor = stack[n-1].vendor
+				stack = stack[:n-1]
+			}
+			if o.canonical && !vendor && dropEmptyContainer(&out, p) {
+				continue
+			}
+		case pieceText, pieceCDATA:
+			if n := len(stack); n != 0 && !stack[n-1].vendor {
+				p = convertText(p, o.text[stack[n-1].name])
+			}
+		}
+		out = append(out, p)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(b)))
+	for _, p := range out {
+		buf.Write(p.raw)
+	}
+	return buf.Bytes()
+}
+
+var (
+	modelOnce sync.Once
+	modelled  map[string]bool
+	raw       map[string]bool
+)
+
+// modelledElements returns the names of the elements of the VAST model
+func modelledElements() map[string]bool {
+	modelOnce.Do(collectModel)
+	return modelled
+}
+
+// rawElements returns the names of the elements whose content is kept as
+// inner XML, such as extensions
+func rawElements() map[string]bool {
+	modelOnce.Do(collectModel)
+	return raw
+}
+
+func collectModel() {
+	modelled = map[string]bool{"VAST": true}
+	raw = make(map[string]bool)
+	collectElements(reflect.TypeOf(VAST{}), "VAST", make(map[reflect.Type]bool))
+}
+
+func collectElements(t reflect.Type, name string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Contains(t.Field(i).Tag.Get("xml"), ",innerxml") {
+			raw[name] = true
+		}
+	}
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("xml")
+		if f.PkgPath != "" || f.Name == "XMLName" || tag == "-" || strings.Contains(tag, ",attr") ||
+			strings.Contains(tag, ",chardata") || strings.Contains(tag, ",cdata") || strings.Contains(tag, ",innerxml") {
+			continue
+		}
+		path := strings.Split(tag, ",")[0]
+		if path == "" {
+			path = f.Name
+		}
+		names := strings.Split(path, ">")
+		for _, n := range names {
+			modelled[n] = true
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Slice || ft.Kind() == reflect.Ptr {
+			if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Uint8 {
+				break
+			}
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			collectElements(ft, names[len(names)-1], seen)
+		}
+	}
+}
+
+// dropEmptyContainer removes the start piece matching end from out, along
+// with its indentation, if the element is an empty container.
+func dropEmptyContainer(out *[]piece, end piece) bool {
+	n := len(*out)
+	if n == 0 || !emptyContainers[end.name] {
+		return false
+	}
+	if start := (*out)[n-1]; start.kind != pieceStart || start.name != end.name || start.attrs {
+		return false
+	}
+
+	n--
+	if n > 0 && (*out)[n-1].kind == pieceText && len(bytes.TrimSpace((*out)[n-1].raw)) == 0 {
+		n--
+	}
+	*out = (*out)[:n]
+	return true
+}
+
+func convertText(p piece, mode textMode) piece {
+	switch {
+	case mode == textCDATA && p.kind == pieceText:
+		text := unescapeText(p.raw)
+		if strings.TrimSpace(text) == "" {
+			return p
+		}
+		raw := "<![CDATA[" + strings.Replace(text, "]]>", "]]]]><![CDATA[>", -1) + "]]>"
+		return piece{kind: pieceCDATA, raw: []byte(raw)}
+	case mode == textEscaped && p.kind == pieceCDATA:
+		data := p.raw[len("<![CDATA[") : len(p.raw)-len("]]>")]
+		buf := new(bytes.Buffer)
+		_ = xml.EscapeText(buf, data)
+		return piece{kind: pieceText, raw: buf.Bytes()}
+	}
+	return p
+}
+
+func unescapeText(raw []byte) string {
+	var buf strings.Builder
+	dec := xml.NewDecoder(strings.NewReader("<x>" + string(raw) + "</x>"))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			buf.Write(cd)
+		}
+	}
+	return buf.String()
+}
+
+// scanPieces splits a well-formed XML fragment into raw pieces
+func scanPieces(b []byte) []piece {
+	var pieces []piece
+	for len(b) != 0 {
+		var n int
+		var p piece
+
+		switch {
+		case b[0] != '<':
+			n = bytes.IndexByte(b, '<')
+			if n < 0 {
+				n = len(b)
+			}
+			p.kind = pieceText
+		case bytes.HasPrefix(b, []byte("<![CDATA[")):
+			n = indexAfter(b, "]]>")
+			p.kind = pieceCDATA
+		case bytes.HasPrefix(b, []byte("<!--")):
+			n = indexAfter(b, "-->")
+			p.kind = pieceOther
+		case bytes.HasPrefix(b, []byte("<?")):
+			n = indexAfter(b, "?>")
+			p.kind = pieceOther
+		case bytes.HasPrefix(b, []byte("</")):
+			n = indexAfter(b, ">")
+			p.kind = pieceEnd
+			p.name = strings.TrimSpace(string(b[2 : n-1]))
+		default:
+			n = tagEnd(b)
+			tag := b[1 : n-1]
+			p.kind = pieceStart
+			if bytes.HasSuffix(tag, []byte("/")) {
+				p.kind = pieceOther
+			}
+			if i := bytes.IndexAny(tag, " \t\r\n/"); i > -1 {
+				p.name = string(tag[:i])
+				p.attrs = len(bytes.Trim(tag[i:], " \t\r\n/")) != 0
+			} else {
+				p.name = string(tag)
+			}
+		}
+
+		p.raw = b[:n]
+		pieces = append(pieces, p)
+		b = b[n:]
+	}
+	return pieces
+}
+
+// indexAfter returns the index following the first occurrence of sep, or the
+// length of b if not found
+func indexAfter(b []byte, sep string) int {
+	if i := bytes.Index(b, []byte(sep)); i > -1 {
+		return i + len(sep)
+	}
+	return len(b)
+}
+
+// tagEnd returns the index following the end of the tag at the start of b,
+// skipping quoted attribute values
+func tagEnd(b []byte) int {
+	var quote byte
+	for i := 1; i < len(b); i++ {
+		switch c := b[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return len(b)
+}
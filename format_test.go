@@ -0,0 +1,101 @@
+package vast
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encode options", func() {
+	var subject *VAST
+
+	BeforeEach(func() {
+		subject = &VAST{
+			Version: "3.0",
+			Ads: []Ad{{
+				ID: "1",
+				InLine: &InLine{
+					AdSystem:    &AdSystem{Name: "system"},
+					AdTitle:     &AdTitle{Name: "title"},
+					Advertiser:  &Advertiser{Name: "advertiser"},
+					Impressions: []Impression{{URI: "http://example.com/impression?a=1&b=2"}},
+					Creatives: []Creative{{
+						Linear: &Linear{Duration: durationPtr(0), CreativeExtensions: &CreativeExtensions{Extensions: []Extension{
+							{Type: "d", Data: []byte("<D/>")},
+							{Type: "c", Data: []byte("<C/>")},
+						}}},
+					}},
+					Extensions: &Extensions{Extensions: []Extension{
+						{Type: "b", Data: []byte("<B/>")},
+						{Type: "a", Data: []byte("<A2/>")},
+						{Type: "a", Data: []byte("<A1/>")},
+					}},
+				},
+			}},
+		}
+	})
+
+	It("should indent", func() {
+		b, err := Marshal(&VAST{Version: "3.0", Ads: []Ad{{ID: "1"}}}, WithIndent("", "  "))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(HaveSuffix("<VAST version=\"3.0\">\n  <Ad id=\"1\"></Ad>\n</VAST>"))
+	})
+
+	It("should produce a canonical form", func() {
+		b, err := Marshal(subject, Canonical(), WithIndent("", "  "))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("TrackingEvents"))
+		Expect(string(b)).NotTo(ContainSubstring("MediaFiles"))
+		Expect(string(b)).To(ContainSubstring("<Linear>\n            <Duration>00:00:00</Duration>\n            <CreativeExtensions>"))
+		Expect(bytes.Index(b, []byte("<C/>"))).To(BeNumerically("<", bytes.Index(b, []byte("<D/>"))))
+		Expect(string(b)).To(ContainSubstring(`<Extension type="a"><A1/></Extension>`))
+		Expect(bytes.Index(b, []byte("<A1/>"))).To(BeNumerically("<", bytes.Index(b, []byte("<A2/>"))))
+		Expect(bytes.Index(b, []byte("<A2/>"))).To(BeNumerically("<", bytes.Index(b, []byte("<B/>"))))
+
+		// the value is left untouched
+		Expect(subject.Ads[0].InLine.Extensions.Extensions[0].Type).To(Equal("b"))
+		Expect(subject.Ads[0].InLine.Creatives[0].Linear.CreativeExtensions.Extensions[0].Type).To(Equal("d"))
+
+		b2, err := Marshal(subject, Canonical(), WithIndent("", "  "))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b2).To(Equal(b))
+
+		v, err := Decode(bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0].InLine.Impressions).To(Equal(subject.Ads[0].InLine.Impressions))
+	})
+
+	It("should choose between CDATA and escaped text", func() {
+		b, err := Marshal(subject, WithEscapedText("Impression"), WithCDATA("Advertiser"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("<Impression>http://example.com/impression?a=1&amp;b=2</Impression>"))
+		Expect(string(b)).To(ContainSubstring("<Advertiser><![CDATA[advertiser]]></Advertiser>"))
+
+		v, err := Decode(bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0].InLine.Impressions).To(Equal(subject.Ads[0].InLine.Impressions))
+		Expect(v.Ads[0].InLine.Advertiser.Name).To(Equal("advertiser"))
+	})
+
+	It("should leave vendor content untouched", func() {
+		const data = `<Impression><![CDATA[http://vendor]]></Impression><TrackingEvents></TrackingEvents>`
+		subject.Ads[0].InLine.Extensions = &Extensions{Extensions: []Extension{{Type: "vendor", Data: []byte(data)}}}
+		b, err := Marshal(subject, Canonical(), WithEscapedText("Impression"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring(`<Extension type="vendor">` + data + `</Extension>`))
+		Expect(string(b)).To(ContainSubstring("<Impression>http://example.com/impression?a=1&amp;b=2</Impression>"))
+	})
+
+	It("should split CDATA terminators", func() {
+		subject.Ads[0].InLine.Advertiser.Name = "a]]>b"
+		b, err := Marshal(subject, WithCDATA("Advertiser"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("<Advertiser><![CDATA[a]]]]><![CDATA[>b]]></Advertiser>"))
+
+		v, err := Decode(bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0].InLine.Advertiser.Name).To(Equal("a]]>b"))
+	})
+
+})
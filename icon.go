@@ -0,0 +1,78 @@
+package vast
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Icon alignments
+const (
+	IconAlignLeft   = "left"
+	IconAlignRight  = "right"
+	IconAlignTop    = "top"
+	IconAlignBottom = "bottom"
+)
+
+// IconPosition is the horizontal or vertical position of an icon, either an
+// offset in pixels from the left or top of the player, or an alignment.
+type IconPosition struct {
+	// One of "left" or "right" horizontally, "top" or "bottom" vertically.
+	// If empty, the position is given by Pixels.
+	Align string
+	// The offset in pixels
+	Pixels int
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (p IconPosition) MarshalText() ([]byte, error) {
+	if p.Align != "" {
+		return []byte(p.Align), nil
+	}
+	return []byte(strconv.Itoa(p.Pixels)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Values
+// which are not a number of pixels are kept as the alignment, use Validate
+// to check them.
+func (p *IconPosition) UnmarshalText(data []byte) error {
+	*p = IconPosition{}
+
+	s := strings.TrimSpace(string(data))
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		p.Pixels = n
+		return nil
+	}
+	p.Align = s
+	return nil
+}
+
+// Timing returns when the icon should be shown and hidden during the
+// playback of a linear creative of the given duration. An icon without
+// offset is shown from the start, and an icon without duration until the
+// end of the creative, or forever if the creative duration is unknown.
+func (icon *Icon) Timing(total time.Duration) (show, hide time.Duration) {
+	if icon.Offset != nil {
+		show = icon.Offset.Resolve(Duration(total))
+	}
+
+	hide = total
+	if total <= 0 {
+		hide = math.MaxInt64
+	}
+	if icon.Duration != nil {
+		hide = show + time.Duration(*icon.Duration)
+		if total > 0 && hide > total {
+			hide = total
+		}
+	}
+	return show, hide
+}
+
+// VisibleAt returns true if the icon should be displayed at the playhead of
+// a linear creative of the given duration
+func (icon *Icon) VisibleAt(playhead, total time.Duration) bool {
+	show, hide := icon.Timing(total)
+	return playhead >= show && playhead < hide
+}
@@ -0,0 +1,54 @@
+package vast
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IconPosition", func() {
+
+	DescribeTable("text",
+		func(s string, exp IconPosition, out string) {
+			var p IconPosition
+			Expect(p.UnmarshalText([]byte(s))).To(Succeed())
+			Expect(p).To(Equal(exp))
+
+			b, err := p.MarshalText()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(out))
+		},
+		Entry("pixels", " 24 ", IconPosition{Pixels: 24}, "24"),
+		Entry("zero", "0", IconPosition{}, "0"),
+		Entry("alignment", "left", IconPosition{Align: IconAlignLeft}, "left"),
+		Entry("invalid", "-5", IconPosition{Align: "-5"}, "-5"),
+	)
+
+})
+
+var _ = Describe("Icon", func() {
+
+	DescribeTable("timing",
+		func(icon Icon, total, show, hide time.Duration) {
+			s, h := icon.Timing(total)
+			Expect(s).To(Equal(show))
+			Expect(h).To(Equal(hide))
+		},
+		Entry("whole creative", Icon{}, 30*time.Second, time.Duration(0), 30*time.Second),
+		Entry("unknown duration", Icon{}, time.Duration(0), time.Duration(0), time.Duration(math.MaxInt64)),
+		Entry("time offset", Icon{Offset: &Offset{Duration: durationPtr(5 * time.Second)}, Duration: durationPtr(10 * time.Second)}, 30*time.Second, 5*time.Second, 15*time.Second),
+		Entry("percent offset", Icon{Offset: &Offset{Percent: 0.6}}, 20*time.Second, 12*time.Second, 20*time.Second),
+		Entry("clamped", Icon{Offset: &Offset{Percent: 0.5}, Duration: durationPtr(time.Minute)}, 30*time.Second, 15*time.Second, 30*time.Second),
+	)
+
+	It("should be visible during its timing", func() {
+		icon := Icon{Offset: &Offset{Duration: durationPtr(5 * time.Second)}, Duration: durationPtr(10 * time.Second)}
+		Expect(icon.VisibleAt(4*time.Second, 30*time.Second)).To(BeFalse())
+		Expect(icon.VisibleAt(5*time.Second, 30*time.Second)).To(BeTrue())
+		Expect(icon.VisibleAt(15*time.Second, 30*time.Second)).To(BeFalse())
+	})
+
+})
@@ -0,0 +1,102 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+)
+
+// MaxLenientRecoveries is the number of invalid values FromXMLLenient and
+// DecodeLenient recover from. Each recovery parses the document again, so
+// the bound keeps the cost of broken documents linear.
+const MaxLenientRecoveries = 32
+
+// FromXMLLenient parses a document like FromXML, but recovers from invalid
+// values: the element or attribute holding the value, such as a malformed
+// Duration or offset, is dropped and the rest of the document is kept. The
+// dropped values are reported as parse errors, in document order.
+//
+// Malformed XML can't be recovered from, it is still returned as a
+// *ParseError, as is the invalid value following MaxLenientRecoveries
+// recovered ones.
+func FromXMLLenient(xmlStr []byte) (*VAST, []*ParseError, error) {
+	return decodeLenient(xmlStr, strip(xmlStr), strippedPosition(xmlStr))
+}
+
+// DecodeLenient parses a document from a reader like Decode, recovering
+// from invalid values like FromXMLLenient.
+func DecodeLenient(r io.Reader) (*VAST, []*ParseError, error) {
+	orig, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc := append([]byte(nil), orig...)
+	return decodeLenient(orig, doc, func(offset int64) int64 { return offset })
+}
+
+// decodeLenient decodes doc, blanking the failing elements and attributes
+// until it succeeds. Blanking keeps the offsets of doc, so errors are still
+// located in orig.
+func decodeLenient(orig, doc []byte, position func(offset int64) int64) (*VAST, []*ParseError, error) {
+	var recovered []*ParseError
+	for {
+		var v VAST
+		d := xml.NewDecoder(bytes.NewReader(doc))
+		err := d.Decode(&v)
+		if err == nil {
+			return &v, recovered, nil
+		}
+
+		e, f := locate(orig, doc, d.InputOffset(), err, position)
+		if e.Code != ErrorCodeSchemaValidation || f == nil || len(recovered) == MaxLenientRecoveries || !blankFailure(doc, f, err) {
+			return nil, recovered, e
+		}
+		recovered = append(recovered, e)
+	}
+}
+
+// blankFailure replaces the attribute or element of f holding the value
+// which caused err with spaces. It returns false if there was nothing left to
+// blank.
+func blankFailure(doc []byte, f *parseFrame, err error) bool {
+	d := xml.NewDecoder(bytes.NewReader(doc[f.start:]))
+	if _, err := d.RawToken(); err != nil {
+		return false
+	}
+	tag := doc[f.start : f.start+d.InputOffset()]
+
+	if a := f.offendingAttr(err); a != nil {
+		re := regexp.MustCompile(`\s(?:[\w.-]+:)?` + regexp.QuoteMeta(a.Name.Local) + `\s*=\s*(?:"[^"]*"|'[^']*')`)
+		if loc := re.FindIndex(tag); loc != nil {
+			return blank(tag[loc[0]:loc[1]])
+		}
+	}
+
+	// skip to the end of the element
+	for depth := 1; depth > 0; {
+		tok, err := d.RawToken()
+		if err != nil {
+			return false
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return blank(doc[f.start : f.start+d.InputOffset()])
+}
+
+// blank replaces b with spaces, returning false if it was blank already
+func blank(b []byte) bool {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return false
+	}
+	for i := range b {
+		b[i] = ' '
+	}
+	return true
+}
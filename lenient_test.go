@@ -0,0 +1,120 @@
+package vast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lenient parsing", func() {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<VAST version="3.0">
+	<Ad id="1" sequence="1">
+		<InLine>
+			<Creatives>
+				<Creative>
+					<Linear>
+						<Duration>00:00:15</Duration>
+					</Linear>
+				</Creative>
+			</Creatives>
+		</InLine>
+	</Ad>
+	<Ad id="2" sequence="2">
+		<InLine>
+			<Creatives>
+				<Creative>
+					<Linear skipoffset="never">
+						<Duration>thirty seconds</Duration>
+						<TrackingEvents>
+							<Tracking event="progress" offset="half"><![CDATA[http://example.com/progress]]></Tracking>
+							<Tracking event="start"><![CDATA[http://example.com/start]]></Tracking>
+						</TrackingEvents>
+						<MediaFiles>
+							<MediaFile delivery="progressive" type="video/mp4" width="wide" height="360"><![CDATA[http://example.com/video.mp4]]></MediaFile>
+						</MediaFiles>
+					</Linear>
+				</Creative>
+			</Creatives>
+		</InLine>
+	</Ad>
+	<Ad id="3" sequence="3">
+		<InLine>
+			<AdTitle>third</AdTitle>
+		</InLine>
+	</Ad>
+</VAST>`
+
+	check := func(v *VAST, recovered []*ParseError, err error) {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads).To(HaveLen(3))
+		Expect(v.Ads[2].InLine.AdTitle.Name).To(Equal("third"))
+
+		Expect(*v.Ads[0].InLine.Creatives[0].Linear.Duration).To(Equal(Duration(15 * time.Second)))
+		l := v.Ads[1].InLine.Creatives[0].Linear
+		Expect(l.SkipOffset).To(BeNil())
+		Expect(l.Duration).To(BeNil())
+		Expect(l.TrackingEvents).To(Equal([]Tracking{
+			{Event: EventProgress, URI: "http://example.com/progress"},
+			{Event: EventStart, URI: "http://example.com/start"},
+		}))
+		Expect(l.MediaFiles).To(HaveLen(1))
+		Expect(l.MediaFiles[0].Width).To(Equal(0))
+		Expect(l.MediaFiles[0].Height).To(Equal(360))
+		Expect(l.MediaFiles[0].URI).To(Equal(URI("http://example.com/video.mp4")))
+
+		var problems []string
+		for _, e := range recovered {
+			Expect(e.Code).To(Equal(ErrorCodeSchemaValidation))
+			problems = append(problems, e.Path+" "+e.Text)
+		}
+		Expect(problems).To(Equal([]string{
+			"VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear never",
+			"VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/Duration thirty seconds",
+			"VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/TrackingEvents/Tracking[1] half",
+			"VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/MediaFiles/MediaFile[1] wide",
+		}))
+		Expect(recovered[1].Line).To(Equal(19))
+	}
+
+	It("should drop invalid values", func() {
+		check(FromXMLLenient([]byte(doc)))
+	})
+
+	It("should drop invalid values while decoding", func() {
+		check(DecodeLenient(strings.NewReader(doc)))
+	})
+
+	It("should not modify the input", func() {
+		data := []byte(doc)
+		_, _, err := DecodeLenient(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = FromXMLLenient(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(doc))
+	})
+
+	It("should bound the number of recoveries", func() {
+		var ads strings.Builder
+		for i := 0; i <= MaxLenientRecoveries; i++ {
+			ads.WriteString(`<Ad><InLine><Creatives><Creative><Linear><Duration>never</Duration></Linear></Creative></Creatives></InLine></Ad>`)
+		}
+		v, recovered, err := FromXMLLenient([]byte(`<VAST version="3.0">` + ads.String() + `</VAST>`))
+		Expect(v).To(BeNil())
+		Expect(recovered).To(HaveLen(MaxLenientRecoveries))
+		Expect(err).To(BeAssignableToTypeOf(&ParseError{}))
+		Expect(err.(*ParseError).Path).To(Equal(fmt.Sprintf("VAST/Ad[%d]/InLine/Creatives/Creative[1]/Linear/Duration", MaxLenientRecoveries+1)))
+	})
+
+	It("should fail on malformed XML", func() {
+		v, recovered, err := FromXMLLenient([]byte(strings.Replace(doc, "</AdTitle>", "</Title>", 1)))
+		Expect(v).To(BeNil())
+		Expect(recovered).To(HaveLen(4))
+		Expect(ErrorCodeFor(err)).To(Equal(ErrorCodeXMLParsing))
+	})
+
+})
@@ -0,0 +1,282 @@
+package vast
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BreakPosition identifies the position of an ad break within the content
+type BreakPosition int
+
+// Ad break positions as defined by the [BREAKPOSITION] macro
+const (
+	BreakPositionPreRoll    BreakPosition = 1
+	BreakPositionMidRoll    BreakPosition = 2
+	BreakPositionPostRoll   BreakPosition = 3
+	BreakPositionStandalone BreakPosition = 4
+)
+
+// Macros holds the values substituted for the macros of tracking and click
+// URIs. Macros without a value are left untouched, except [CACHEBUSTING] and
+// [TIMESTAMP] which are generated when not set.
+type Macros struct {
+	// The error code, substituted for [ERRORCODE]
+	ErrorCode ErrorCode
+	// The reason code for verification tracking, substituted for [REASON]
+	Reason int
+	// The time of the event, substituted for [TIMESTAMP]. Defaults to now.
+	Timestamp time.Time
+	// A random number, substituted for [CACHEBUSTING]. Defaults to a random
+	// 8 digits number.
+	CacheBusting string
+	// The playhead of the content, substituted for [CONTENTPLAYHEAD] and [MEDIAPLAYHEAD]
+	ContentPlayhead *Duration
+	// The playhead of the ad, substituted for [ADPLAYHEAD]
+	AdPlayhead *Duration
+	// The position of the ad break, substituted for [BREAKPOSITION]
+	BreakPosition BreakPosition
+	// The URI of the ad asset being played, substituted for [ASSETURI]
+	AssetURI string
+	// The URI of the content, substituted for [CONTENTURI]
+	ContentURI string
+	// The URL of the page on which the ad is played, substituted for [PAGEURL]
+	PageURL string
+	// The domain of the page on which the ad is played, substituted for [DOMAIN]
+	Domain string
+	// The bundle ID of the app in which the ad is played, substituted for [APPBUNDLE]
+	AppBundle string
+	// The IAB TCF consent string, substituted for [GDPRCONSENT]
+	GDPRConsent string
+	// Whether the user has opted out of ad tracking, substituted for [LIMITADTRACKING]
+	LimitAdTracking *bool
+	// The resettable device identifier, substituted for [IFA]
+	IFA string
+	// The type of the device identifier, substituted for [IFATYPE]
+	IFAType string
+	// The user agent of the device, substituted for [DEVICEUA]
+	DeviceUA string
+	// The IP address of the device, substituted for [DEVICEIP]
+	DeviceIP string
+	// The user agent of the server making the request on behalf of the device,
+	// substituted for [SERVERUA]
+	ServerUA string
+
+	custom map[string]string
+}
+
+// Set registers a custom macro, which takes precedence over the predefined
+// ones. The name must be given without brackets.
+func (m *Macros) Set(name, value string) *Macros {
+	if m.custom == nil {
+		m.custom = make(map[string]string)
+	}
+	m.custom[name] = value
+	return m
+}
+
+// Lookup returns the value of a macro. The name must be given without brackets.
+func (m *Macros) Lookup(name string) (string, bool) {
+	if v, ok := m.custom[name]; ok {
+		return v, true
+	}
+
+	switch name {
+	case "ERRORCODE":
+		if m.ErrorCode != 0 {
+			return strconv.Itoa(int(m.ErrorCode)), true
+		}
+	case "REASON":
+		if m.Reason != 0 {
+			return strconv.Itoa(m.Reason), true
+		}
+	case "TIMESTAMP":
+		ts := m.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		return ts.Format("2006-01-02T15:04:05.000-07:00"), true
+	case "CACHEBUSTING":
+		if m.CacheBusting != "" {
+			return m.CacheBusting, true
+		}
+		return strconv.Itoa(10000000 + rand.Intn(90000000)), true
+	case "CONTENTPLAYHEAD", "MEDIAPLAYHEAD":
+		if m.ContentPlayhead != nil {
+			return formatPlayhead(*m.ContentPlayhead), true
+		}
+	case "ADPLAYHEAD":
+		if m.AdPlayhead != nil {
+			return formatPlayhead(*m.AdPlayhead), true
+		}
+	case "BREAKPOSITION":
+		if m.BreakPosition != 0 {
+			return strconv.Itoa(int(m.BreakPosition)), true
+		}
+	case "LIMITADTRACKING":
+		if m.LimitAdTracking != nil {
+			if *m.LimitAdTracking {
+				return "1", true
+			}
+			return "0", true
+		}
+	default:
+		if v := m.stringValue(name); v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (m *Macros) stringValue(name string) string {
+	switch name {
+	case "ASSETURI":
+		return m.AssetURI
+	case "CONTENTURI":
+		return m.ContentURI
+	case "PAGEURL":
+		return m.PageURL
+	case "DOMAIN":
+		return m.Domain
+	case "APPBUNDLE":
+		return m.AppBundle
+	case "GDPRCONSENT":
+		return m.GDPRConsent
+	case "IFA":
+		return m.IFA
+	case "IFATYPE":
+		return m.IFAType
+	case "DEVICEUA":
+		return m.DeviceUA
+	case "DEVICEIP":
+		return m.DeviceIP
+	case "SERVERUA":
+		return m.ServerUA
+	}
+	return ""
+}
+
+// Expand returns the URI with all known macros substituted by their
+// percent-encoded values. Macros may be written as [NAME] or %5BNAME%5D.
+// Generated values, such as [CACHEBUSTING], are the same for every occurrence
+// within the URI.
+func (s URI) Expand(m *Macros) URI {
+	if m == nil {
+		m = new(Macros)
+	}
+
+	str := string(s)
+	if !strings.Contains(str, "[") && !strings.Contains(str, "%5B") && !strings.Contains(str, "%5b") {
+		return s
+	}
+
+	var buf strings.Builder
+	cache := make(map[string]string)
+	for len(str) != 0 {
+		name, n := scanMacro(str)
+		if n == 0 {
+			buf.WriteByte(str[0])
+			str = str[1:]
+			continue
+		}
+
+		val, ok := cache[name]
+		if !ok {
+			if val, ok = m.Lookup(name); ok {
+				val = escapeMacroValue(val)
+				cache[name] = val
+			}
+		}
+		if ok {
+			buf.WriteString(val)
+		} else {
+			buf.WriteString(str[:n])
+		}
+		str = str[n:]
+	}
+	return URI(buf.String())
+}
+
+// ExpandURIs expands the macros of multiple URIs, using the same generated
+// values for all of them.
+func ExpandURIs(uris []URI, m *Macros) []URI {
+	if m == nil {
+		m = new(Macros)
+	}
+
+	fixed := *m
+	if fixed.CacheBusting == "" {
+		fixed.CacheBusting, _ = m.Lookup("CACHEBUSTING")
+	}
+	if fixed.Timestamp.IsZero() {
+		fixed.Timestamp = time.Now()
+	}
+
+	res := make([]URI, 0, len(uris))
+	for _, u := range uris {
+		res = append(res, u.Expand(&fixed))
+	}
+	return res
+}
+
+// scanMacro returns the name and the length of the macro at the start of s.
+// It returns a zero length if s does not start with a macro.
+func scanMacro(s string) (string, int) {
+	var open, close int
+	switch {
+	case s[0] == '[':
+		open = 1
+	case len(s) >= 3 && strings.EqualFold(s[:3], "%5B"):
+		open = 3
+	default:
+		return "", 0
+	}
+
+	i := open
+	for i < len(s) && isMacroChar(s[i]) {
+		i++
+	}
+	if i == open {
+		return "", 0
+	}
+
+	switch {
+	case i < len(s) && s[i] == ']':
+		close = 1
+	case i+3 <= len(s) && strings.EqualFold(s[i:i+3], "%5D"):
+		close = 3
+	default:
+		return "", 0
+	}
+	return s[open:i], i + close
+}
+
+func isMacroChar(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// escapeMacroValue percent-encodes all characters except the unreserved ones
+// of RFC 3986.
+func escapeMacroValue(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func formatPlayhead(d Duration) string {
+	h := d / Duration(time.Hour)
+	m := d % Duration(time.Hour) / Duration(time.Minute)
+	s := d % Duration(time.Minute) / Duration(time.Second)
+	ms := d % Duration(time.Second) / Duration(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
@@ -0,0 +1,66 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Macros", func() {
+	ts := time.Date(2016, 1, 17, 8, 15, 7, 127000000, time.FixedZone("", 5*3600))
+	lat := true
+
+	macros := &Macros{
+		ErrorCode:       ErrorCodeFileNotFound,
+		Timestamp:       ts,
+		CacheBusting:    "12345678",
+		ContentPlayhead: durationPtr(90*time.Minute + 1500*time.Millisecond),
+		AdPlayhead:      durationPtr(5 * time.Second),
+		BreakPosition:   BreakPositionMidRoll,
+		AssetURI:        "https://example.com/video.mp4?a=1&b=2",
+		PageURL:         "https://example.com/page with space",
+		GDPRConsent:     "BOLqFHuOLqFHuAABAENAAAAAAAAoAAA",
+		LimitAdTracking: &lat,
+		IFA:             "aa-bb",
+		DeviceUA:        "Mozilla/5.0 (X11)",
+	}
+
+	DescribeTable("expand",
+		func(s string, exp string) {
+			Expect(URI(s).Expand(macros)).To(Equal(URI(exp)))
+		},
+		Entry("no macros", "http://example.com/a?b=c", "http://example.com/a?b=c"),
+		Entry("error code", "http://example.com/e?c=[ERRORCODE]", "http://example.com/e?c=401"),
+		Entry("timestamp", "http://example.com/?t=[TIMESTAMP]", "http://example.com/?t=2016-01-17T08%3A15%3A07.127%2B05%3A00"),
+		Entry("cache busting", "http://example.com/?cb=[CACHEBUSTING]", "http://example.com/?cb=12345678"),
+		Entry("playheads", "http://example.com/?c=[CONTENTPLAYHEAD]&a=[ADPLAYHEAD]", "http://example.com/?c=01%3A30%3A01.500&a=00%3A00%3A05.000"),
+		Entry("break position", "http://example.com/?bp=[BREAKPOSITION]", "http://example.com/?bp=2"),
+		Entry("asset URI", "http://example.com/?u=[ASSETURI]", "http://example.com/?u=https%3A%2F%2Fexample.com%2Fvideo.mp4%3Fa%3D1%26b%3D2"),
+		Entry("page URL", "http://example.com/?p=[PAGEURL]", "http://example.com/?p=https%3A%2F%2Fexample.com%2Fpage%20with%20space"),
+		Entry("privacy", "http://example.com/?g=[GDPRCONSENT]&l=[LIMITADTRACKING]&i=[IFA]", "http://example.com/?g=BOLqFHuOLqFHuAABAENAAAAAAAAoAAA&l=1&i=aa-bb"),
+		Entry("device UA", "http://example.com/?ua=[DEVICEUA]", "http://example.com/?ua=Mozilla%2F5.0%20%28X11%29"),
+		Entry("encoded brackets", "http://example.com/?e=%5BERRORCODE%5D&f=%5bERRORCODE%5d", "http://example.com/?e=401&f=401"),
+		Entry("unknown macros", "http://example.com/?x=[UNKNOWN]&r=[REASON]", "http://example.com/?x=[UNKNOWN]&r=[REASON]"),
+		Entry("non-macros", "http://example.com/?x=[a]&y=[]&z=[ERRORCODE", "http://example.com/?x=[a]&y=[]&z=[ERRORCODE"),
+	)
+
+	It("should support custom macros", func() {
+		m := new(Macros).Set("PARTNER", "a b").Set("ERRORCODE", "override")
+		Expect(URI("http://example.com/?p=[PARTNER]&e=[ERRORCODE]").Expand(m)).To(Equal(URI("http://example.com/?p=a%20b&e=override")))
+	})
+
+	It("should generate values", func() {
+		uri := URI("http://example.com/?a=[CACHEBUSTING]&b=[CACHEBUSTING]&t=[TIMESTAMP]").Expand(nil)
+		Expect(string(uri)).To(MatchRegexp(`^http://example.com/\?a=(\d{8})&b=(\d{8})&t=\d{4}-\d\d-\d\dT`))
+		Expect(string(uri)[22:30]).To(Equal(string(uri)[33:41]))
+	})
+
+	It("should share generated values across URIs", func() {
+		uris := ExpandURIs([]URI{"http://a/?cb=[CACHEBUSTING]", "http://b/?cb=[CACHEBUSTING]"}, nil)
+		Expect(uris).To(HaveLen(2))
+		Expect(string(uris[0])[len("http://a/?cb="):]).To(Equal(string(uris[1])[len("http://b/?cb="):]))
+	})
+
+})
@@ -0,0 +1,163 @@
+package vast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PlayerCapabilities describes the media files a player can play and the
+// conditions it plays them in
+type PlayerCapabilities struct {
+	// The supported MIME types, e.g. "video/mp4". Any type is accepted if
+	// empty.
+	MIMETypes []string
+	// The supported delivery methods, "progressive" or "streaming". Any
+	// method is accepted if empty.
+	Delivery []string
+	// The supported API frameworks, e.g. "VPAID". Interactive media files
+	// with any other framework are rejected.
+	APIFrameworks []string
+	// The supported codecs, e.g. "avc1" or "H.264". A codec matches RFC 6381
+	// codec strings sharing its prefix, so "avc1" matches "avc1.42E01E".
+	// Any codec is accepted if empty.
+	Codecs []string
+	// The dimensions of the video player in pixels, zero if unknown
+	Width, Height int
+	// The available bandwidth in Kbps, zero if unknown
+	Bandwidth int
+}
+
+// MediaFileRejection is a media file that does not match the player
+// capabilities
+type MediaFileRejection struct {
+	MediaFile MediaFile
+	// Why the media file was rejected
+	Reason string
+}
+
+// MediaFileSelection is the result of SelectMediaFile
+type MediaFileSelection struct {
+	// The matching media files, best first
+	MediaFiles []MediaFile
+	// The media files that do not match, in document order
+	Rejected []MediaFileRejection
+}
+
+// SelectMediaFile filters the media files supported by a player and ranks
+// them by closeness to the player dimensions and bandwidth.
+//
+// Media files with unknown dimensions or bitrate are ranked after close
+// matches, and exceeding the bandwidth costs twice as much as falling short
+// of it. Equally ranked media files keep their document order.
+//
+// It returns ErrorCodeNoSupportedMedia if no media file matches.
+func SelectMediaFile(files []MediaFile, caps PlayerCapabilities) (MediaFileSelection, error) {
+	var sel MediaFileSelection
+	var scores []float64
+	for _, f := range files {
+		if reason := caps.reject(f); reason != "" {
+			sel.Rejected = append(sel.Rejected, MediaFileRejection{MediaFile: f, Reason: reason})
+			continue
+		}
+		sel.MediaFiles = append(sel.MediaFiles, f)
+		scores = append(scores, caps.dimensionScore(f)+caps.bitrateScore(f))
+	}
+	if len(sel.MediaFiles) == 0 {
+		return sel, ErrorCodeNoSupportedMedia
+	}
+
+	sort.Stable(byScore{sel.MediaFiles, scores})
+	return sel, nil
+}
+
+func (c PlayerCapabilities) reject(f MediaFile) string {
+	mime := strings.TrimSpace(strings.SplitN(f.Type, ";", 2)[0])
+	switch {
+	case len(c.MIMETypes) != 0 && !containsFold(c.MIMETypes, mime):
+		return fmt.Sprintf("unsupported type %q", f.Type)
+	case len(c.Delivery) != 0 && !containsFold(c.Delivery, strings.TrimSpace(f.Delivery)):
+		return fmt.Sprintf("unsupported delivery %q", f.Delivery)
+	case f.APIFramework != "" && !containsFold(c.APIFrameworks, f.APIFramework):
+		return fmt.Sprintf("unsupported API framework %q", f.APIFramework)
+	case len(c.Codecs) != 0 && f.Codec != "" && !c.supportsCodec(f.Codec):
+		return fmt.Sprintf("unsupported codec %q", f.Codec)
+	}
+	return ""
+}
+
+func (c PlayerCapabilities) supportsCodec(codec string) bool {
+	codec = strings.TrimSpace(codec)
+	for _, s := range c.Codecs {
+		if strings.EqualFold(s, codec) {
+			return true
+		}
+		if len(codec) > len(s) && codec[len(s)] == '.' && strings.EqualFold(s, codec[:len(s)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// dimensionScore returns the relative distance between the media file and
+// player dimensions
+func (c PlayerCapabilities) dimensionScore(f MediaFile) float64 {
+	if c.Width <= 0 || c.Height <= 0 {
+		return 0
+	}
+	if f.Width <= 0 || f.Height <= 0 {
+		return 1
+	}
+	return relativeDistance(f.Width, c.Width) + relativeDistance(f.Height, c.Height)
+}
+
+// bitrateScore returns the relative distance between the media file bitrate
+// and the available bandwidth. Exceeding the bandwidth costs twice as much.
+func (c PlayerCapabilities) bitrateScore(f MediaFile) float64 {
+	if c.Bandwidth <= 0 {
+		return 0
+	}
+
+	min, max := f.MinBitrate, f.MaxBitrate
+	if f.Bitrate > 0 {
+		min, max = f.Bitrate, f.Bitrate
+	}
+	switch {
+	case min <= 0 && max <= 0:
+		return 1
+	case min > c.Bandwidth:
+		return 2 * relativeDistance(min, c.Bandwidth)
+	case max > 0 && max < c.Bandwidth:
+		return relativeDistance(max, c.Bandwidth)
+	}
+	return 0
+}
+
+func relativeDistance(v, target int) float64 {
+	d := float64(v - target)
+	if d < 0 {
+		d = -d
+	}
+	return d / float64(target)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type byScore struct {
+	files  []MediaFile
+	scores []float64
+}
+
+func (s byScore) Len() int           { return len(s.files) }
+func (s byScore) Less(i, j int) bool { return s.scores[i] < s.scores[j] }
+func (s byScore) Swap(i, j int) {
+	s.files[i], s.files[j] = s.files[j], s.files[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
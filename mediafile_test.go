@@ -0,0 +1,77 @@
+package vast
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SelectMediaFile", func() {
+	caps := PlayerCapabilities{
+		MIMETypes:     []string{"video/mp4", "application/x-mpegURL"},
+		Delivery:      []string{"progressive", "streaming"},
+		APIFrameworks: []string{"VPAID"},
+		Codecs:        []string{"avc1", "H.264"},
+		Width:         1280,
+		Height:        720,
+		Bandwidth:     2000,
+	}
+
+	DescribeTable("should reject",
+		func(f MediaFile, reason string) {
+			sel, err := SelectMediaFile([]MediaFile{f}, caps)
+			Expect(err).To(Equal(ErrorCodeNoSupportedMedia))
+			Expect(sel.MediaFiles).To(BeEmpty())
+			Expect(sel.Rejected).To(Equal([]MediaFileRejection{{MediaFile: f, Reason: reason}}))
+		},
+		Entry("type", MediaFile{Type: "video/webm", Delivery: "progressive"}, `unsupported type "video/webm"`),
+		Entry("delivery", MediaFile{Type: "video/mp4", Delivery: "download"}, `unsupported delivery "download"`),
+		Entry("framework", MediaFile{Type: "video/mp4", Delivery: "progressive", APIFramework: "FlashVars"}, `unsupported API framework "FlashVars"`),
+		Entry("codec", MediaFile{Type: "video/mp4", Delivery: "progressive", Codec: "hev1.1.6.L93.B0"}, `unsupported codec "hev1.1.6.L93.B0"`),
+	)
+
+	DescribeTable("should accept",
+		func(f MediaFile) {
+			sel, err := SelectMediaFile([]MediaFile{f}, caps)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sel.MediaFiles).To(Equal([]MediaFile{f}))
+		},
+		Entry("type parameters", MediaFile{Type: "Video/MP4; codecs=avc1", Delivery: "progressive"}),
+		Entry("codec prefix", MediaFile{Type: "video/mp4", Delivery: "progressive", Codec: "avc1.42E01E"}),
+		Entry("codec name", MediaFile{Type: "video/mp4", Delivery: "progressive", Codec: "h.264"}),
+		Entry("framework", MediaFile{Type: "video/mp4", Delivery: "progressive", APIFramework: "vpaid"}),
+	)
+
+	It("should rank media files", func() {
+		files := []MediaFile{
+			{ID: "small", Type: "video/mp4", Delivery: "progressive", Width: 640, Height: 360, Bitrate: 800},
+			{ID: "heavy", Type: "video/mp4", Delivery: "progressive", Width: 1280, Height: 720, Bitrate: 4000},
+			{ID: "webm", Type: "video/webm", Delivery: "progressive", Width: 1280, Height: 720, Bitrate: 1800},
+			{ID: "hd", Type: "video/mp4", Delivery: "progressive", Width: 1280, Height: 720, Bitrate: 1800},
+			{ID: "unknown", Type: "video/mp4", Delivery: "progressive"},
+			{ID: "adaptive", Type: "application/x-mpegURL", Delivery: "streaming", Width: 1280, Height: 720, MinBitrate: 500, MaxBitrate: 5000},
+		}
+
+		sel, err := SelectMediaFile(files, caps)
+		Expect(err).NotTo(HaveOccurred())
+
+		var ids []string
+		for _, f := range sel.MediaFiles {
+			ids = append(ids, f.ID)
+		}
+		Expect(ids).To(Equal([]string{"adaptive", "hd", "small", "heavy", "unknown"}))
+		Expect(sel.Rejected).To(HaveLen(1))
+		Expect(sel.Rejected[0].MediaFile.ID).To(Equal("webm"))
+	})
+
+	It("should keep document order without a target", func() {
+		files := []MediaFile{
+			{ID: "1", Type: "video/mp4", Width: 640, Height: 360},
+			{ID: "2", Type: "video/mp4", Width: 1920, Height: 1080},
+		}
+		sel, err := SelectMediaFile(files, PlayerCapabilities{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sel.MediaFiles).To(Equal(files))
+	})
+
+})
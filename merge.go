@@ -0,0 +1,205 @@
+package vast
+
+// MergeWrapper appends the trackers of a wrapper to an InLine ad, as required
+// by the VAST spec once a wrapper chain has been resolved.
+//
+// Impressions and error pixels are appended to the InLine, and a copy of the
+// wrapper price, if any, replaces the InLine price. Creative trackers
+// are appended to the matching InLine creative of the same kind (linear,
+// companion or nonlinear). A wrapper creative matches by AdID first, then by
+// Sequence and finally by its position among the wrapper creatives of that
+// kind. Companions and nonlinears are matched within their creative the same
+// way. A wrapper creative, companion or nonlinear without counterpart is
+// merged into the first one of its kind so that no tracker is lost; the
+// trackers are dropped only when the InLine has nothing of that kind.
+func MergeWrapper(wrapper *Wrapper, inline *InLine) {
+	if wrapper == nil || inline == nil {
+		return
+	}
+
+	inline.Impressions = append(inline.Impressions, wrapper.Impressions...)
+	inline.Error = append(inline.Error, wrapper.Error...)
+	if wrapper.Pricing != nil {
+		p := *wrapper.Pricing
+		inline.Pricing = &p
+	}
+
+	var linears, companions, nonLinears int
+	for i := range wrapper.Creatives {
+		cw := &wrapper.Creatives[i]
+		if cw.Linear != nil {
+			if c := matchCreative(inline.Creatives, cw, linears, isLinear); c != nil {
+				mergeLinear(cw.Linear, c.Linear)
+			}
+			linears++
+		}
+		if cw.CompanionAds != nil {
+			if c := matchCreative(inline.Creatives, cw, companions, isCompanion); c != nil {
+				mergeCompanionAds(cw.CompanionAds, c.CompanionAds)
+			}
+			companions++
+		}
+		if cw.NonLinearAds != nil {
+			if c := matchCreative(inline.Creatives, cw, nonLinears, isNonLinear); c != nil {
+				mergeNonLinearAds(cw.NonLinearAds, c.NonLinearAds)
+			}
+			nonLinears++
+		}
+	}
+}
+
+// Merge applies MergeWrapper for each wrapper in the chain, innermost
+// first, so that the resolved InLine carries the trackers of all wrappers
+// and the price given by Pricing.
+func (r *ResolvedAd) Merge() {
+	if r.Ad == nil || r.Ad.InLine == nil {
+		return
+	}
+	for i := len(r.Wrappers) - 1; i >= 0; i-- {
+		MergeWrapper(r.Wrappers[i], r.Ad.InLine)
+	}
+}
+
+// adID returns the ad identifier of the creative, whichever its spelling
+func (c *Creative) adID() string {
+	if c.AdID != "" {
+		return c.AdID
+	}
+	return c.AdIDV4
+}
+
+// adID returns the ad identifier of the creative, whichever its spelling
+func (c *CreativeWrapper) adID() string {
+	if c.AdID != "" {
+		return c.AdID
+	}
+	return c.AdIDV4
+}
+
+func isLinear(c *Creative) bool    { return c.Linear != nil }
+func isCompanion(c *Creative) bool { return c.CompanionAds != nil }
+func isNonLinear(c *Creative) bool { return c.NonLinearAds != nil }
+
+// matchCreative finds the InLine creative which should receive the trackers
+// of cw. The pos is the position of cw among the wrapper creatives of the same
+// kind. It returns nil if the InLine has no creative of the kind.
+func matchCreative(creatives []Creative, cw *CreativeWrapper, pos int, kind func(*Creative) bool) *Creative {
+	var candidates []*Creative
+	for i := range creatives {
+		if kind(&creatives[i]) {
+			candidates = append(candidates, &creatives[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if id := cw.adID(); id != "" {
+		for _, c := range candidates {
+			if c.adID() == id {
+				return c
+			}
+		}
+	}
+	if cw.Sequence != 0 {
+		for _, c := range candidates {
+			if c.Sequence == cw.Sequence {
+				return c
+			}
+		}
+	}
+	if pos < len(candidates) {
+		return candidates[pos]
+	}
+	return candidates[0]
+}
+
+func mergeLinear(lw *LinearWrapper, l *Linear) {
+	l.TrackingEvents = append(l.TrackingEvents, lw.TrackingEvents...)
+
+	if lw.VideoClicks == nil {
+		return
+	}
+	if len(lw.VideoClicks.ClickTrackings) == 0 && len(lw.VideoClicks.CustomClicks) == 0 {
+		return
+	}
+	if l.VideoClicks == nil {
+		l.VideoClicks = &VideoClicks{}
+	}
+	l.VideoClicks.ClickTrackings = append(l.VideoClicks.ClickTrackings, lw.VideoClicks.ClickTrackings...)
+	l.VideoClicks.CustomClicks = append(l.VideoClicks.CustomClicks, lw.VideoClicks.CustomClicks...)
+}
+
+func mergeCompanionAds(caw *CompanionAdsWrapper, ca *CompanionAds) {
+	if len(ca.Companions) == 0 {
+		return
+	}
+	for i := range caw.Companions {
+		cw := &caw.Companions[i]
+		c := matchCompanion(ca.Companions, cw, i)
+		c.TrackingEvents = append(c.TrackingEvents, cw.TrackingEvents...)
+		c.CompanionClickTracking = append(c.CompanionClickTracking, cw.CompanionClickTracking...)
+	}
+}
+
+// matchCompanion finds the companion matching cw by ID, then by AdSlotID, then
+// by dimensions and finally by position, falling back to the first one.
+func matchCompanion(companions []Companion, cw *CompanionWrapper, pos int) *Companion {
+	if cw.ID != "" {
+		for i := range companions {
+			if companions[i].ID == cw.ID {
+				return &companions[i]
+			}
+		}
+	}
+	if cw.AdSlotID != "" {
+		for i := range companions {
+			if companions[i].AdSlotID == cw.AdSlotID {
+				return &companions[i]
+			}
+		}
+	}
+	if cw.Width != 0 || cw.Height != 0 {
+		for i := range companions {
+			if companions[i].Width == cw.Width && companions[i].Height == cw.Height {
+				return &companions[i]
+			}
+		}
+	}
+	if pos < len(companions) {
+		return &companions[pos]
+	}
+	return &companions[0]
+}
+
+func mergeNonLinearAds(nlw *NonLinearAdsWrapper, nl *NonLinearAds) {
+	nl.TrackingEvents = append(nl.TrackingEvents, nlw.TrackingEvents...)
+
+	for i := range nlw.NonLinears {
+		w := &nlw.NonLinears[i]
+		// nonlinear elements have no trackers of their own in an InLine
+		nl.TrackingEvents = append(nl.TrackingEvents, w.TrackingEvents...)
+
+		if len(nl.NonLinears) == 0 {
+			continue
+		}
+		n := matchNonLinear(nl.NonLinears, w, i)
+		n.NonLinearClickTracking = append(n.NonLinearClickTracking, w.NonLinearClickTracking...)
+	}
+}
+
+// matchNonLinear finds the nonlinear matching w by ID and finally by position,
+// falling back to the first one.
+func matchNonLinear(nonLinears []NonLinear, w *NonLinearWrapper, pos int) *NonLinear {
+	if w.ID != "" {
+		for i := range nonLinears {
+			if nonLinears[i].ID == w.ID {
+				return &nonLinears[i]
+			}
+		}
+	}
+	if pos < len(nonLinears) {
+		return &nonLinears[pos]
+	}
+	return &nonLinears[0]
+}
@@ -0,0 +1,188 @@
+package vast
+
+import (
+	"encoding/xml"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MergeWrapper", func() {
+
+	load := func(name string) *VAST {
+		v, err := FromXML(mustReadFile(name))
+		Expect(err).NotTo(HaveOccurred())
+		return v
+	}
+
+	It("should merge linear trackers", func() {
+		w := load("testdata/vast_wrapper_linear_1.xml").Ads[0].Wrapper
+		in := load("testdata/vast_inline_linear.xml").Ads[0].InLine
+
+		MergeWrapper(w, in)
+		Expect(in.Impressions).To(Equal([]Impression{
+			{URI: "http://myTrackingURL/impression"},
+			{ID: "foo", URI: "http://myTrackingURL/impression2"},
+			{URI: "http://myTrackingURL/wrapper/impression"},
+		}))
+		Expect(in.Error).To(Equal([]Error{
+			{URI: "http://myErrorURL/error"},
+			{URI: "http://myErrorURL/error2"},
+			{URI: "http://myErrorURL/wrapper/error"},
+		}))
+
+		linear := in.Creatives[0].Linear
+		Expect(linear.TrackingEvents).To(HaveLen(17))
+		Expect(linear.TrackingEvents[16]).To(Equal(Tracking{Event: "fullscreen", URI: "http://myTrackingURL/wrapper/fullscreen"}))
+		Expect(linear.VideoClicks.ClickTrackings).To(Equal([]VideoClick{
+			{URI: "http://myTrackingURL/click"},
+			{URI: "http://myTrackingURL/wrapper/click"},
+		}))
+	})
+
+	It("should merge companion trackers", func() {
+		w := load("testdata/vast_wrapper_linear_2.xml").Ads[0].Wrapper
+		in := load("testdata/vast_inline_linear.xml").Ads[0].InLine
+
+		MergeWrapper(w, in)
+		companions := in.Creatives[1].CompanionAds.Companions
+		Expect(companions[0].TrackingEvents).To(Equal([]Tracking{
+			{Event: "creativeView", URI: "http://myTrackingURL/firstCompanionCreativeView"},
+			{Event: "creativeView", URI: "http://myTrackingURL/wrapper/firstCompanionCreativeView"},
+		}))
+		Expect(companions[1].TrackingEvents).To(BeEmpty())
+	})
+
+	It("should keep the id of companion click trackers", func() {
+		in := &InLine{Creatives: []Creative{
+			{CompanionAds: &CompanionAds{Companions: []Companion{{ID: "c"}}}},
+		}}
+		w := &Wrapper{Creatives: []CreativeWrapper{
+			{CompanionAds: &CompanionAdsWrapper{Companions: []CompanionWrapper{
+				{ID: "c", CompanionClickTracking: []CompanionClickTracking{{ID: "t", URI: "http://click"}}},
+			}}},
+		}}
+
+		MergeWrapper(w, in)
+		Expect(in.Creatives[0].CompanionAds.Companions[0].CompanionClickTracking).To(Equal([]CompanionClickTracking{{ID: "t", URI: "http://click"}}))
+
+		b, err := xml.Marshal(in.Creatives[0].CompanionAds.Companions[0].CompanionClickTracking[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`<CompanionClickTracking id="t"><![CDATA[http://click]]></CompanionClickTracking>`))
+	})
+
+	It("should merge nonlinear trackers", func() {
+		w := load("testdata/vast_wrapper_nonlinear_1.xml").Ads[0].Wrapper
+		in := load("testdata/vast_inline_nonlinear.xml").Ads[0].InLine
+		w.Creatives[1].NonLinearAds.NonLinears = []NonLinearWrapper{
+			{NonLinearClickTracking: []string{"http://myTrackingURL/wrapper/nonlinear/click"}},
+		}
+
+		MergeWrapper(w, in)
+		nl := in.Creatives[0].NonLinearAds
+		Expect(nl.TrackingEvents).To(HaveLen(10))
+		Expect(nl.TrackingEvents[5]).To(Equal(Tracking{Event: "creativeView", URI: "http://myTrackingURL/wrapper/nonlinear/creativeView/creativeView"}))
+		Expect(nl.NonLinears[0].NonLinearClickTracking).To(Equal([]string{"http://myTrackingURL/wrapper/nonlinear/click"}))
+		Expect(nl.NonLinears[1].NonLinearClickTracking).To(BeEmpty())
+	})
+
+	It("should match creatives by AdID", func() {
+		in := &InLine{Creatives: []Creative{
+			{AdID: "a", Linear: &Linear{}},
+			{AdID: "b", Linear: &Linear{}},
+		}}
+		w := &Wrapper{Creatives: []CreativeWrapper{
+			{AdID: "b", Linear: &LinearWrapper{TrackingEvents: []Tracking{{Event: "start", URI: "http://b"}}}},
+		}}
+
+		MergeWrapper(w, in)
+		Expect(in.Creatives[0].Linear.TrackingEvents).To(BeEmpty())
+		Expect(in.Creatives[1].Linear.TrackingEvents).To(Equal([]Tracking{{Event: "start", URI: "http://b"}}))
+	})
+
+	It("should merge unmatched creatives into the first of their kind", func() {
+		in := &InLine{Creatives: []Creative{
+			{Linear: &Linear{}},
+		}}
+		w := &Wrapper{Creatives: []CreativeWrapper{
+			{Linear: &LinearWrapper{TrackingEvents: []Tracking{{Event: "start", URI: "http://first"}}}},
+			{Linear: &LinearWrapper{TrackingEvents: []Tracking{{Event: "start", URI: "http://second"}}}},
+		}}
+
+		MergeWrapper(w, in)
+		Expect(in.Creatives[0].Linear.TrackingEvents).To(Equal([]Tracking{
+			{Event: "start", URI: "http://first"},
+			{Event: "start", URI: "http://second"},
+		}))
+	})
+
+	It("should merge unmatched companions and nonlinears into the first of their kind", func() {
+		in := &InLine{Creatives: []Creative{
+			{CompanionAds: &CompanionAds{Companions: []Companion{{ID: "a"}}}},
+			{NonLinearAds: &NonLinearAds{NonLinears: []NonLinear{{ID: "a"}}}},
+		}}
+		w := &Wrapper{Creatives: []CreativeWrapper{
+			{CompanionAds: &CompanionAdsWrapper{Companions: []CompanionWrapper{
+				{ID: "a", CompanionClickTracking: []CompanionClickTracking{{URI: "http://first"}}},
+				{ID: "b", CompanionClickTracking: []CompanionClickTracking{{URI: "http://second"}}},
+			}}},
+			{NonLinearAds: &NonLinearAdsWrapper{NonLinears: []NonLinearWrapper{
+				{ID: "a", NonLinearClickTracking: []string{"http://first"}},
+				{ID: "b", NonLinearClickTracking: []string{"http://second"}},
+			}}},
+		}}
+
+		MergeWrapper(w, in)
+		Expect(in.Creatives[0].CompanionAds.Companions[0].CompanionClickTracking).To(Equal([]CompanionClickTracking{
+			{URI: "http://first"},
+			{URI: "http://second"},
+		}))
+		Expect(in.Creatives[1].NonLinearAds.NonLinears[0].NonLinearClickTracking).To(Equal([]string{"http://first", "http://second"}))
+	})
+
+	It("should merge resolved chains", func() {
+		in := load("testdata/vast_inline_linear.xml")
+		ra := &ResolvedAd{
+			Ad: &in.Ads[0],
+			Wrappers: []*Wrapper{
+				{Impressions: []Impression{{URI: "http://outer"}}, Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "2"}},
+				{Impressions: []Impression{{URI: "http://inner"}}, Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "1"}},
+			},
+		}
+
+		ra.Merge()
+		Expect(in.Ads[0].InLine.Impressions).To(HaveLen(4))
+		Expect(in.Ads[0].InLine.Impressions[2].URI).To(Equal(URI("http://inner")))
+		Expect(in.Ads[0].InLine.Impressions[3].URI).To(Equal(URI("http://outer")))
+		Expect(in.Ads[0].InLine.Pricing.Value).To(Equal("2"))
+		Expect(in.Ads[0].InLine.Pricing).NotTo(BeIdenticalTo(ra.Wrappers[0].Pricing))
+	})
+
+	It("should replace the InLine price with a copy of the wrapper price", func() {
+		in := &InLine{Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "1"}}
+		w := &Wrapper{Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "2"}}
+
+		MergeWrapper(w, in)
+		Expect(in.Pricing).To(Equal(w.Pricing))
+		Expect(in.Pricing).NotTo(BeIdenticalTo(w.Pricing))
+
+		MergeWrapper(&Wrapper{}, in)
+		Expect(in.Pricing.Value).To(Equal("2"))
+	})
+
+	It("should merge the price of the first priced wrapper", func() {
+		ra := &ResolvedAd{
+			Ad: &Ad{InLine: &InLine{Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "1"}}},
+			Wrappers: []*Wrapper{
+				{},
+				{Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "5"}},
+				{Pricing: &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "3"}},
+			},
+		}
+
+		ra.Merge()
+		Expect(ra.Ad.InLine.Pricing.Value).To(Equal("5"))
+		Expect(ra.Ad.InLine.Pricing).To(Equal(ra.Pricing()))
+	})
+
+})
@@ -1,17 +1,54 @@
 package vast
 
 import (
+	"encoding/xml"
 	"fmt"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+)
+
+var (
+	strictPercent  = regexp.MustCompile(`^(\d{1,3}(?:\.\d+)?)%$`)
+	lenientPercent = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*%$`)
 )
 
 // Offset represents either a vast.Duration or a percentage of the video duration.
 type Offset struct {
 	// If not nil, the Offset is duration based
 	Duration *Duration
-	// If Duration is nil, the Offset is percent based
-	Percent float32
+	// If Duration is nil, the Offset is percent based, as a fraction of 1
+	Percent float64
+}
+
+// ParseOffset parses an offset in the given mode
+func ParseOffset(s string, mode ParseMode) (Offset, error) {
+	if mode == ParseLenient {
+		s = strings.TrimSpace(s)
+	}
+	if !strings.HasSuffix(s, "%") {
+		d, err := ParseDuration(s, mode)
+		if err != nil {
+			return Offset{}, fmt.Errorf("invalid offset: %s", s)
+		}
+		return Offset{Duration: &d}, nil
+	}
+
+	re := lenientPercent
+	if mode == ParseStrict {
+		re = strictPercent
+	}
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return Offset{}, fmt.Errorf("invalid offset: %s", s)
+	}
+	p, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || p > 100 {
+		return Offset{}, fmt.Errorf("invalid offset: %s", s)
+	}
+	return Offset{Percent: p / 100}, nil
 }
 
 // MarshalText implements the encoding.TextMarshaler interface.
@@ -19,22 +56,92 @@ func (o Offset) MarshalText() ([]byte, error) {
 	if o.Duration != nil {
 		return o.Duration.MarshalText()
 	}
-	return []byte(fmt.Sprintf("%d%%", int(o.Percent*100))), nil
+	// round off the error of the division by 100 in ParseOffset, which keeps
+	// percentages with up to 9 decimals
+	p := math.Round(o.Percent*100*1e9) / 1e9
+	return []byte(strconv.FormatFloat(p, 'f', -1, 64) + "%"), nil
 }
 
-// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// UnmarshalText implements the encoding.TextUnmarshaler interface. The
+// offset is parsed in lenient mode.
 func (o *Offset) UnmarshalText(data []byte) error {
-	if strings.HasSuffix(string(data), "%") {
-		p, err := strconv.ParseInt(string(data[:len(data)-1]), 10, 8)
-		if err != nil {
-			return fmt.Errorf("invalid offset: %s", data)
-		}
-		o.Percent = float32(p) / 100
-		return nil
-	} else {
-		o.Percent = 0
-	}
-	var d Duration
-	o.Duration = &d
-	return o.Duration.UnmarshalText(data)
+	offset, err := ParseOffset(string(data), ParseLenient)
+	if err != nil {
+		return err
+	}
+	*o = offset
+	return nil
+}
+
+// UnmarshalXMLAttr implements the xml.UnmarshalerAttr interface, naming the
+// attribute in errors
+func (o *Offset) UnmarshalXMLAttr(attr xml.Attr) error {
+	return newAttrError(attr, o.UnmarshalText([]byte(attr.Value)))
+}
+
+// Resolve returns the absolute time of the offset within a creative of the
+// given duration. Percent based offsets resolve to 0 when the duration is
+// unknown.
+func (o Offset) Resolve(total Duration) time.Duration {
+	if o.Duration != nil {
+		return time.Duration(*o.Duration)
+	}
+	if total <= 0 {
+		return 0
+	}
+	// round to avoid precision errors, e.g. 60% of 20s being 11.999999999s
+	return time.Duration(float64(total) * o.Percent).Round(time.Millisecond)
+}
+
+// resolvable returns true if the offset can be resolved against total
+func (o Offset) resolvable(total Duration) bool {
+	return o.Duration != nil || total > 0
+}
+
+// Compare compares the absolute times of two offsets within a creative of
+// the given duration. It returns -1 if o is before other, 1 if it is after
+// and 0 if both are at the same time.
+func (o Offset) Compare(other Offset, total Duration) int {
+	a, b := o.Resolve(total), other.Resolve(total)
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Before returns true if o is before other within a creative of the given
+// duration
+func (o Offset) Before(other Offset, total Duration) bool {
+	return o.Compare(other, total) < 0
+}
+
+// SkipAt returns the time after which the skip control should be shown. It
+// returns false if the creative is not skippable, or if the skip offset is
+// a percentage and the duration of the creative is unknown.
+func (l *Linear) SkipAt() (time.Duration, bool) {
+	if l.SkipOffset == nil {
+		return 0, false
+	}
+	var total Duration
+	if l.Duration != nil {
+		total = *l.Duration
+	}
+	if !l.SkipOffset.resolvable(total) {
+		return 0, false
+	}
+	return l.SkipOffset.Resolve(total), true
+}
+
+// ProgressAt returns the time at which a progress event is due within a
+// creative of the given duration. It returns false for other events, progress
+// events without offset, or percent based offsets when the duration is
+// unknown.
+func (t *Tracking) ProgressAt(total Duration) (time.Duration, bool) {
+	if t.Event.Normalize() != EventProgress || t.Offset == nil || !t.Offset.resolvable(total) {
+		return 0, false
+	}
+	return t.Offset.Resolve(total), true
 }
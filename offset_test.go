@@ -1,6 +1,8 @@
 package vast
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
@@ -16,6 +18,9 @@ var _ = Describe("Offset", func() {
 		},
 		Entry("0%", &Offset{}, "0%"),
 		Entry("10%", &Offset{Percent: 0.1}, "10%"),
+		Entry("12.5%", &Offset{Percent: 0.125}, "12.5%"),
+		Entry("33.3%", &Offset{Percent: 0.333}, "33.3%"),
+		Entry("100%", &Offset{Percent: 1}, "100%"),
 		Entry("00:00:00", &Offset{Duration: durationPtr(0)}, "00:00:00"),
 	)
 
@@ -29,11 +34,87 @@ var _ = Describe("Offset", func() {
 		Entry("0%", "0%", 0.0, nil),
 		Entry("10%", "10%", 0.1, nil),
 		Entry("00:00:00", "00:00:00", 0.0, durationPtr(0)),
+		Entry("12.5%", "12.5%", 0.125, nil),
+		Entry("lax percent", " 7. %", 0.07, nil),
+		Entry("lax duration", "0:01:00", 0.0, durationPtr(time.Minute)),
 	)
 
+	DescribeTable("parse strictly",
+		func(s string, valid bool) {
+			_, err := ParseOffset(s, ParseStrict)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError("invalid offset: " + s))
+			}
+		},
+		Entry("percent", "12.5%", true),
+		Entry("duration", "00:00:05.000", true),
+		Entry("whitespace", "12 %", false),
+		Entry("over 100%", "100.5%", false),
+		Entry("single digit", "0:00:05", false),
+	)
+
+	It("should round-trip", func() {
+		for _, s := range []string{"0%", "12.5%", "33.3%", "66.67%", "99.99%", "57.123456%", "0.000001%", "00:00:05.125", "02:30:00"} {
+			o, err := ParseOffset(s, ParseStrict)
+			Expect(err).NotTo(HaveOccurred())
+			b, err := o.MarshalText()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(s))
+		}
+	})
+
 	It("should fail to unmarshal bad inputs", func() {
 		o := new(Offset)
 		Expect(o.UnmarshalText([]byte("abc%"))).To(MatchError("invalid offset: abc%"))
+		Expect(o.UnmarshalText([]byte("101%"))).To(MatchError("invalid offset: 101%"))
+		Expect(o.UnmarshalText([]byte("5s"))).To(MatchError("invalid offset: 5s"))
+	})
+
+	DescribeTable("resolve",
+		func(o Offset, total Duration, exp time.Duration) {
+			Expect(o.Resolve(total)).To(Equal(exp))
+		},
+		Entry("duration", Offset{Duration: durationPtr(5 * time.Second)}, Duration(30*time.Second), 5*time.Second),
+		Entry("duration without total", Offset{Duration: durationPtr(5 * time.Second)}, Duration(0), 5*time.Second),
+		Entry("percent", Offset{Percent: 0.6}, Duration(20*time.Second), 12*time.Second),
+		Entry("percent without total", Offset{Percent: 0.6}, Duration(0), time.Duration(0)),
+	)
+
+	It("should compare", func() {
+		total := Duration(20 * time.Second)
+		a := Offset{Duration: durationPtr(5 * time.Second)}
+		b := Offset{Percent: 0.25}
+		c := Offset{Percent: 0.5}
+		Expect(a.Compare(b, total)).To(Equal(0))
+		Expect(a.Compare(c, total)).To(Equal(-1))
+		Expect(c.Compare(a, total)).To(Equal(1))
+		Expect(a.Before(c, total)).To(BeTrue())
+		Expect(c.Before(a, total)).To(BeFalse())
 	})
 
+	DescribeTable("skip",
+		func(l Linear, exp time.Duration, skippable bool) {
+			at, ok := l.SkipAt()
+			Expect(ok).To(Equal(skippable))
+			Expect(at).To(Equal(exp))
+		},
+		Entry("not skippable", Linear{Duration: durationPtr(30 * time.Second)}, time.Duration(0), false),
+		Entry("duration", Linear{SkipOffset: &Offset{Duration: durationPtr(5 * time.Second)}}, 5*time.Second, true),
+		Entry("percent", Linear{SkipOffset: &Offset{Percent: 0.2}, Duration: durationPtr(30 * time.Second)}, 6*time.Second, true),
+		Entry("percent without duration", Linear{SkipOffset: &Offset{Percent: 0.2}}, time.Duration(0), false),
+	)
+
+	DescribeTable("progress",
+		func(t Tracking, exp time.Duration, due bool) {
+			at, ok := t.ProgressAt(Duration(20 * time.Second))
+			Expect(ok).To(Equal(due))
+			Expect(at).To(Equal(exp))
+		},
+		Entry("progress", Tracking{Event: EventProgress, Offset: &Offset{Percent: 0.1}}, 2*time.Second, true),
+		Entry("without offset", Tracking{Event: EventProgress}, time.Duration(0), false),
+		Entry("other event", Tracking{Event: EventStart, Offset: &Offset{Percent: 0.1}}, time.Duration(0), false),
+	)
+
 })
@@ -0,0 +1,305 @@
+package vast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParseError is the error returned by FromXML and Decode when a document
+// can't be parsed. It locates the failure in the original document.
+type ParseError struct {
+	// Line and Column of the failure in the original document, starting at 1.
+	// The column is counted in bytes.
+	Line, Column int
+	// Path of the element being parsed, e.g. VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/Duration.
+	// Repeated elements are indexed from 1, as in the issues of Validate.
+	Path string
+	// The offending text: the invalid attribute value or element text, or
+	// the line of a malformed document
+	Text string
+	// The name of the attribute holding the invalid value, if any
+	Attr string
+	// ErrorCodeXMLParsing for malformed XML, or ErrorCodeSchemaValidation
+	// for well-formed documents not matching the VAST model
+	Code ErrorCode
+	// The underlying encoding/xml or value parsing error
+	Err error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	// syntax errors carry the line in the stripped document, which is not
+	// the line of the original document
+	var msg interface{} = e.Err
+	if err, ok := e.Err.(*xml.SyntaxError); ok {
+		msg = err.Msg
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %v", e.Line, e.Column, e.Path, msg)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// decode decodes doc into v, returning a ParseError on failure. The
+// document was derived from orig by dropping bytes, position maps offsets in
+// doc to offsets in orig.
+func decode(orig, doc []byte, v *VAST, position func(offset int64) int64) error {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	if err := d.Decode(v); err != nil {
+		return newParseError(orig, doc, d.InputOffset(), err, position)
+	}
+	return nil
+}
+
+// newParseError locates err, which occurred at offset in doc
+func newParseError(orig, doc []byte, offset int64, err error, position func(offset int64) int64) *ParseError {
+	e, _ := locate(orig, doc, offset, err, position)
+	return e
+}
+
+// locate returns the error located in orig, along with the failing element
+// if any
+func locate(orig, doc []byte, offset int64, err error, position func(offset int64) int64) (*ParseError, *parseFrame) {
+	if offset > int64(len(doc)) {
+		offset = int64(len(doc))
+	}
+	e := &ParseError{Code: parseErrorCode(err), Err: err}
+
+	// replay the document up to the failure to find the element path
+	var open, closed *parseFrame
+	var last xml.Token
+	d := xml.NewDecoder(bytes.NewReader(doc[:offset]))
+	for {
+		start := d.InputOffset()
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			path := name
+			if open != nil {
+				open.children[name]++
+				path = open.path + "/" + name
+				if repeatedElements()[name] {
+					path = fmt.Sprintf("%s[%d]", path, open.children[name])
+				}
+			}
+			open = &parseFrame{parent: open, path: path, start: start, attrs: t.Attr, children: make(map[string]int)}
+		case xml.EndElement:
+			if open != nil {
+				closed, open = open, open.parent
+			}
+		case xml.CharData:
+			if open != nil {
+				open.text = append(open.text, t...)
+			}
+		}
+		last = tok
+	}
+
+	// values are unmarshalled once their element is read, so an invalid
+	// value belongs to the element just opened or closed
+	subject := open
+	if _, ok := last.(xml.EndElement); ok && e.Code == ErrorCodeSchemaValidation {
+		subject = closed
+	}
+	if subject != nil {
+		e.Path = subject.path
+	}
+
+	pos := int(position(offset))
+	start := bytes.LastIndexByte(orig[:pos], '\n') + 1
+	e.Line = bytes.Count(orig[:start], []byte("\n")) + 1
+	e.Column = pos - start + 1
+	if e.Code == ErrorCodeSchemaValidation && subject != nil {
+		if a := subject.offendingAttr(err); a != nil {
+			e.Attr, e.Text = a.Name.Local, a.Value
+		} else {
+			e.Text = strings.TrimSpace(string(subject.text))
+		}
+	} else {
+		end := bytes.IndexByte(orig[pos:], '\n')
+		if end < 0 {
+			end = len(orig) - pos
+		}
+		e.Text = strings.TrimSpace(string(orig[start : pos+end]))
+	}
+	return e, subject
+}
+
+// parseErrorCode returns the error code of a decoding error
+func parseErrorCode(err error) ErrorCode {
+	if _, ok := err.(*xml.SyntaxError); ok || err == io.EOF {
+		return ErrorCodeXMLParsing
+	}
+	return ErrorCodeSchemaValidation
+}
+
+// countingReader counts the lines of a streamed document, to locate errors
+// without keeping the document
+type countingReader struct {
+	r *bufio.Reader
+	// bytes read, and the offsets of the current and previous lines
+	n, line, prevLine int64
+	lines             int
+}
+
+func newCountingReader(r io.Reader) *countingReader {
+	return &countingReader{r: bufio.NewReader(r)}
+}
+
+// ReadByte lets encoding/xml read byte by byte, so that no more than what
+// it consumes is counted
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.count(b)
+	}
+	return b, err
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for _, b := range p[:n] {
+		c.count(b)
+	}
+	return n, err
+}
+
+func (c *countingReader) count(b byte) {
+	c.n++
+	if b == '\n' {
+		c.lines++
+		c.prevLine, c.line = c.line, c.n
+	}
+}
+
+// position returns the line and column of offset. encoding/xml reads at
+// most one byte past the offset of the error, so offset is on the current
+// or the previous line.
+func (c *countingReader) position(offset int64) (line, column int) {
+	if offset >= c.line {
+		return c.lines + 1, int(offset-c.line) + 1
+	}
+	return c.lines, int(offset-c.prevLine) + 1
+}
+
+type parseFrame struct {
+	parent   *parseFrame
+	path     string
+	start    int64
+	attrs    []xml.Attr
+	text     []byte
+	children map[string]int
+}
+
+// attrError is an error parsing the value of an attribute, naming the
+// attribute. encoding/xml doesn't tell which attribute failed.
+type attrError struct {
+	name string
+	err  error
+}
+
+// newAttrError wraps err, if not nil, with the name of the attribute
+func newAttrError(attr xml.Attr, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &attrError{name: attr.Name.Local, err: err}
+}
+
+func (e *attrError) Error() string {
+	return e.err.Error()
+}
+
+func (e *attrError) Unwrap() error {
+	return e.err
+}
+
+// offendingAttr returns the attribute which caused err: the attribute named
+// by the VAST types, or the attribute holding the number which encoding/xml
+// failed to parse
+func (f *parseFrame) offendingAttr(err error) *xml.Attr {
+	var attrErr *attrError
+	var numErr *strconv.NumError
+	for i, a := range f.attrs {
+		switch {
+		case errors.As(err, &attrErr):
+			if a.Name.Local == attrErr.name {
+				return &f.attrs[i]
+			}
+		case errors.As(err, &numErr):
+			if a.Value == numErr.Num {
+				return &f.attrs[i]
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	repeatedOnce sync.Once
+	repeated     map[string]bool
+)
+
+// repeatedElements returns the names of the elements which may occur more
+// than once in their parent, and are indexed in paths
+func repeatedElements() map[string]bool {
+	repeatedOnce.Do(func() {
+		repeated = make(map[string]bool)
+		collectRepeated(reflect.TypeOf(VAST{}), make(map[reflect.Type]bool))
+	})
+	return repeated
+}
+
+func collectRepeated(t reflect.Type, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Name == "XMLName" {
+			continue
+		}
+		tag := f.Tag.Get("xml")
+		if tag == "-" || strings.Contains(tag, ",attr") || strings.Contains(tag, ",chardata") ||
+			strings.Contains(tag, ",cdata") || strings.Contains(tag, ",innerxml") || strings.Contains(tag, ",any") {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if i := strings.LastIndex(name, ">"); i >= 0 {
+			name = name[i+1:]
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			repeated[name] = true
+			ft = ft.Elem()
+		}
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			collectRepeated(ft, seen)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package vast
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseError", func() {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<VAST version="3.0">
+	<Ad id="1">
+		<InLine>
+			<AdTitle>first</AdTitle>
+		</InLine>
+	</Ad>
+	<Ad id="2">
+		<InLine>
+			<Creatives>
+				<Creative>
+					<Linear skipoffset="%SKIP%">
+						<Duration>%DURATION%</Duration>
+						<MediaFiles>
+							<MediaFile delivery="progressive" type="video/mp4" width="%WIDTH%" height="360">
+								<![CDATA[http://example.com/video.mp4]]>
+							</MediaFile>
+						</MediaFiles>
+					</Linear>
+				</Creative>
+			</Creatives>
+		</InLine>
+	</Ad>
+</VAST>`
+
+	defaults := strings.NewReplacer("%SKIP%", "5%", "%DURATION%", "00:00:30", "%WIDTH%", "640")
+	document := func(old, new string) []byte {
+		return []byte(defaults.Replace(strings.Replace(doc, old, new, 1)))
+	}
+
+	It("should parse the valid document", func() {
+		_, err := FromXML(document("%SKIP%", "00:00:05"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("locate",
+		func(data []byte, exp ParseError) {
+			for _, parse := range []func() error{
+				func() error { _, err := FromXML(data); return err },
+				func() error { _, err := Decode(bytes.NewReader(data)); return err },
+			} {
+				err := parse()
+				var perr *ParseError
+				Expect(errors.As(err, &perr)).To(BeTrue())
+				Expect(perr.Line).To(Equal(exp.Line))
+				Expect(perr.Column).To(Equal(exp.Column))
+				Expect(perr.Path).To(Equal(exp.Path))
+				Expect(perr.Text).To(Equal(exp.Text))
+				Expect(perr.Attr).To(Equal(exp.Attr))
+				Expect(perr.Code).To(Equal(exp.Code))
+				Expect(ErrorCodeFor(err)).To(Equal(exp.Code))
+			}
+		},
+		Entry("element value", document("%DURATION%", "abc"), ParseError{
+			Line: 13, Column: 31, Path: "VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/Duration", Text: "abc", Code: ErrorCodeSchemaValidation,
+		}),
+		Entry("attribute value", document("%SKIP%", "soon"), ParseError{
+			Line: 12, Column: 32, Path: "VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear", Text: "soon", Attr: "skipoffset", Code: ErrorCodeSchemaValidation,
+		}),
+		Entry("numeric attribute", document("%WIDTH%", "wide"), ParseError{
+			Line: 15, Column: 85, Path: "VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/MediaFiles/MediaFile[1]", Text: "wide", Attr: "width", Code: ErrorCodeSchemaValidation,
+		}),
+		Entry("attribute value shared with another attribute", document(`skipoffset="%SKIP%"`, `id="soon" skipoffset="soon"`), ParseError{
+			Line: 12, Column: 42, Path: "VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear", Text: "soon", Attr: "skipoffset", Code: ErrorCodeSchemaValidation,
+		}),
+		Entry("malformed", document("</AdTitle>", "</Title>"), ParseError{
+			Line: 5, Column: 26, Path: "VAST/Ad[1]/InLine/AdTitle", Text: "<AdTitle>first</Title>", Code: ErrorCodeXMLParsing,
+		}),
+		Entry("truncated", []byte("<VAST version=\"3.0\">\n\t<Ad>"), ParseError{
+			Line: 2, Column: 6, Path: "VAST/Ad[1]", Text: "<Ad>", Code: ErrorCodeXMLParsing,
+		}),
+	)
+
+	DescribeTable("locate in unseekable streams",
+		func(data []byte, exp ParseError) {
+			_, err := Decode(struct{ io.Reader }{bytes.NewReader(data)})
+			var perr *ParseError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Line).To(Equal(exp.Line))
+			Expect(perr.Column).To(Equal(exp.Column))
+			Expect(perr.Path).To(BeEmpty())
+			Expect(perr.Code).To(Equal(exp.Code))
+		},
+		Entry("element value", document("%DURATION%", "abc"), ParseError{Line: 13, Column: 31, Code: ErrorCodeSchemaValidation}),
+		Entry("malformed", document("</AdTitle>", "</Title>"), ParseError{Line: 5, Column: 26, Code: ErrorCodeXMLParsing}),
+		Entry("truncated", []byte("<VAST version=\"3.0\">\n\t<Ad>"), ParseError{Line: 2, Column: 6, Code: ErrorCodeXMLParsing}),
+		Entry("before a newline", []byte("<VAST>\n<Ad>\n\t<InLine></Ad>\n</VAST>"), ParseError{Line: 3, Column: 15, Code: ErrorCodeXMLParsing}),
+	)
+
+	It("should locate errors from the position of seekable streams", func() {
+		r := bytes.NewReader(append([]byte("junk"), document("%DURATION%", "abc")...))
+		_, err := r.Seek(4, io.SeekStart)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = Decode(r)
+		var perr *ParseError
+		Expect(errors.As(err, &perr)).To(BeTrue())
+		Expect(perr.Line).To(Equal(13))
+		Expect(perr.Column).To(Equal(31))
+		Expect(perr.Text).To(Equal("abc"))
+	})
+
+	DescribeTable("map stripped offsets",
+		func(offset, exp int64) {
+			Expect(strippedPosition([]byte("a\n\tb\nc"))(offset)).To(Equal(exp))
+		},
+		Entry("start", int64(0), int64(0)),
+		Entry("before newlines", int64(1), int64(1)),
+		Entry("after newlines", int64(2), int64(4)),
+		Entry("end", int64(3), int64(6)),
+		Entry("past the end", int64(4), int64(6)),
+	)
+
+	It("should describe the failure", func() {
+		_, err := FromXML(document("%DURATION%", "abc"))
+		Expect(err).To(MatchError("line 13, column 31: VAST/Ad[2]/InLine/Creatives/Creative[1]/Linear/Duration: invalid duration: abc"))
+	})
+
+	It("should describe syntax errors with the original line only", func() {
+		_, err := FromXML([]byte("<VAST>\n<Ad>\n\t<InLine></Ad></VAST>"))
+		Expect(err).To(MatchError("line 3, column 15: VAST/Ad[1]/InLine: element <InLine> closed by </Ad>"))
+	})
+
+})
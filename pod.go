@@ -0,0 +1,90 @@
+package vast
+
+import (
+	"sort"
+	"time"
+)
+
+// Pod splits the ads of a VAST document into an ad pod, the ads with a
+// sequence meant to be played one after the other, and a buffet of
+// stand-alone ads.
+//
+// As defined by VAST 3 and 4, stand-alone ads may replace pod ads that fail
+// to play, and players that don't support pods play a single stand-alone ad.
+type Pod struct {
+	// The ads with a sequence, in playback order
+	Ads []Ad
+	// The ads without a sequence, in document order
+	Buffet []Ad
+
+	// The number of buffet ads used as replacements
+	used int
+}
+
+// NewPod returns the pod of a VAST document. Ads sharing a sequence keep
+// their document order.
+func NewPod(v *VAST) *Pod {
+	p := new(Pod)
+	for _, ad := range v.Ads {
+		if ad.Sequence > 0 {
+			p.Ads = append(p.Ads, ad)
+		} else {
+			p.Buffet = append(p.Buffet, ad)
+		}
+	}
+	sort.SliceStable(p.Ads, func(i, j int) bool {
+		return p.Ads[i].Sequence < p.Ads[j].Sequence
+	})
+	return p
+}
+
+// Duration returns the total duration of the pod ads linear creatives.
+// Wrapper ads don't define a duration and are counted as zero.
+func (p *Pod) Duration() time.Duration {
+	var total time.Duration
+	for i := range p.Ads {
+		total += adDuration(&p.Ads[i])
+	}
+	return total
+}
+
+// Single returns the ad to play by players that don't support pods: the
+// first stand-alone ad, or the first pod ad if there are none.
+func (p *Pod) Single() *Ad {
+	switch {
+	case len(p.Buffet) != 0:
+		return &p.Buffet[0]
+	case len(p.Ads) != 0:
+		return &p.Ads[0]
+	}
+	return nil
+}
+
+// Replace replaces the ith pod ad, which failed to play, with the next
+// unused buffet ad. The replacement takes the sequence of the failed ad.
+// It returns false if the buffet is exhausted.
+func (p *Pod) Replace(i int) (*Ad, bool) {
+	if i < 0 || i >= len(p.Ads) || p.used >= len(p.Buffet) {
+		return nil, false
+	}
+
+	ad := p.Buffet[p.used]
+	ad.Sequence = p.Ads[i].Sequence
+	p.Ads[i] = ad
+	p.used++
+	return &p.Ads[i], true
+}
+
+// adDuration returns the duration of the first linear creative of an inline
+// ad
+func adDuration(ad *Ad) time.Duration {
+	if ad.InLine == nil {
+		return 0
+	}
+	for _, c := range ad.InLine.Creatives {
+		if c.Linear != nil && c.Linear.Duration != nil {
+			return time.Duration(*c.Linear.Duration)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pod", func() {
+	linear := func(id string, seq int, d time.Duration) Ad {
+		return Ad{ID: id, Sequence: seq, InLine: &InLine{Creatives: []Creative{
+			{CompanionAds: &CompanionAds{}},
+			{Linear: &Linear{Duration: durationPtr(d)}},
+		}}}
+	}
+
+	var subject *Pod
+
+	BeforeEach(func() {
+		subject = NewPod(&VAST{Ads: []Ad{
+			linear("buffet1", 0, 15*time.Second),
+			linear("third", 3, 30*time.Second),
+			linear("first", 1, 15*time.Second),
+			{ID: "wrapper", Sequence: 2, Wrapper: &Wrapper{}},
+			linear("buffet2", 0, 10*time.Second),
+			linear("second", 2, 20*time.Second),
+		}})
+	})
+
+	It("should split and sort ads", func() {
+		var ids []string
+		for _, ad := range subject.Ads {
+			ids = append(ids, ad.ID)
+		}
+		Expect(ids).To(Equal([]string{"first", "wrapper", "second", "third"}))
+		Expect(subject.Buffet).To(HaveLen(2))
+		Expect(subject.Buffet[0].ID).To(Equal("buffet1"))
+		Expect(subject.Duration()).To(Equal(65 * time.Second))
+	})
+
+	It("should replace failed ads", func() {
+		ad, ok := subject.Replace(1)
+		Expect(ok).To(BeTrue())
+		Expect(ad.ID).To(Equal("buffet1"))
+		Expect(ad.Sequence).To(Equal(2))
+		Expect(subject.Ads[1].ID).To(Equal("buffet1"))
+		Expect(subject.Duration()).To(Equal(80 * time.Second))
+
+		ad, ok = subject.Replace(3)
+		Expect(ok).To(BeTrue())
+		Expect(ad.ID).To(Equal("buffet2"))
+
+		_, ok = subject.Replace(0)
+		Expect(ok).To(BeFalse())
+		_, ok = subject.Replace(4)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should pick a single ad", func() {
+		Expect(subject.Single().ID).To(Equal("buffet1"))
+		Expect(NewPod(&VAST{Ads: []Ad{linear("a", 2, 0), linear("b", 1, 0)}}).Single().ID).To(Equal("b"))
+		Expect(NewPod(&VAST{}).Single()).To(BeNil())
+	})
+
+})
@@ -0,0 +1,89 @@
+package vast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PricingModel is the pricing model of a Pricing element
+type PricingModel string
+
+// Pricing models defined by the spec
+const (
+	// Cost per click
+	PricingModelCPC PricingModel = "cpc"
+	// Cost per thousand impressions
+	PricingModelCPM PricingModel = "cpm"
+	// Cost per engagement
+	PricingModelCPE PricingModel = "cpe"
+	// Cost per view
+	PricingModelCPV PricingModel = "cpv"
+)
+
+// Normalize returns the lowercase pricing model if it is known, or m
+// otherwise
+func (m PricingModel) Normalize() PricingModel {
+	n := PricingModel(strings.ToLower(strings.TrimSpace(string(m))))
+	if n.IsKnown() {
+		return n
+	}
+	return m
+}
+
+// IsKnown returns true if m matches one of the pricing models defined by the
+// spec, regardless of case
+func (m PricingModel) IsKnown() bool {
+	switch PricingModel(strings.ToLower(strings.TrimSpace(string(m)))) {
+	case PricingModelCPC, PricingModelCPM, PricingModelCPE, PricingModelCPV:
+		return true
+	}
+	return false
+}
+
+// Amount returns the numeric value of the price. It fails for obfuscated
+// values.
+func (p *Pricing) Amount() (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(p.Value), 64)
+}
+
+// Pricing returns the price of the ad. As required by the spec, the price
+// of the first wrapper of the chain defining one prevails over the price of
+// the inline ad. It returns nil if there is no price.
+func (r *ResolvedAd) Pricing() *Pricing {
+	for _, w := range r.Wrappers {
+		if w.Pricing != nil {
+			return w.Pricing
+		}
+	}
+	if r.Ad != nil && r.Ad.InLine != nil {
+		return r.Ad.InLine.Pricing
+	}
+	return nil
+}
+
+// isCurrency returns true if code is an active ISO 4217 currency code
+func isCurrency(code string) bool {
+	return currencies[strings.ToUpper(code)]
+}
+
+// currencies are the active ISO 4217 currency codes
+var currencies = make(map[string]bool)
+
+func init() {
+	for _, code := range strings.Fields(currencyCodes) {
+		currencies[code] = true
+	}
+}
+
+const currencyCodes = "" +
+	"AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND " +
+	"BOB BOV BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU " +
+	"CRC CUC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS " +
+	"GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY " +
+	"KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA " +
+	"MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD " +
+	"OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK " +
+	"SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD " +
+	"TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU " +
+	"XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW " +
+	"ZWG ZWL"
@@ -0,0 +1,67 @@
+package vast
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pricing", func() {
+
+	DescribeTable("models",
+		func(m PricingModel, known bool, norm PricingModel) {
+			Expect(m.IsKnown()).To(Equal(known))
+			Expect(m.Normalize()).To(Equal(norm))
+		},
+		Entry("cpm", PricingModel("cpm"), true, PricingModelCPM),
+		Entry("upper case", PricingModel(" CPV"), true, PricingModelCPV),
+		Entry("unknown", PricingModel("cpa"), false, PricingModel("cpa")),
+	)
+
+	It("should parse amounts", func() {
+		n, err := (&Pricing{Value: " 2.50 "}).Amount()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(2.5))
+
+		_, err = (&Pricing{Value: "AbCd=="}).Amount()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should prefer the price of the first wrapper", func() {
+		outer := &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "3"}
+		inner := &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "2"}
+		inline := &Pricing{Model: PricingModelCPM, Currency: "USD", Value: "1"}
+
+		ra := &ResolvedAd{
+			Ad:       &Ad{InLine: &InLine{Pricing: inline}},
+			Wrappers: []*Wrapper{{}, {Pricing: outer}, {Pricing: inner}},
+		}
+		Expect(ra.Pricing()).To(BeIdenticalTo(outer))
+
+		ra.Wrappers = nil
+		Expect(ra.Pricing()).To(BeIdenticalTo(inline))
+		Expect((&ResolvedAd{}).Pricing()).To(BeNil())
+	})
+
+	It("should be validated", func() {
+		v := &VAST{Version: "3.0", Ads: []Ad{{Wrapper: &Wrapper{
+			AdSystem:     &AdSystem{Name: "test"},
+			VASTAdTagURI: TagURI{Name: "http://example.com/vast.xml"},
+			Impressions:  []Impression{{URI: "http://example.com/imp"}},
+			Pricing:      &Pricing{Model: "cpa", Currency: "usd", Value: "1.5"},
+		}}, {Wrapper: &Wrapper{
+			AdSystem:     &AdSystem{Name: "test"},
+			VASTAdTagURI: TagURI{Name: "http://example.com/vast.xml"},
+			Impressions:  []Impression{{URI: "http://example.com/imp"}},
+			Pricing:      &Pricing{Model: "CPC", Currency: "ABC"},
+		}}}}
+
+		Expect(Validate(v, "")).To(Equal([]Issue{
+			{Severity: SeverityError, Path: "VAST/Ad[1]/Wrapper/Pricing", Message: `model must be one of cpc, cpm, cpe or cpv, got "cpa"`},
+			{Severity: SeverityWarning, Path: "VAST/Ad[1]/Wrapper/Pricing", Message: `currency "usd" should be spelled "USD"`},
+			{Severity: SeverityError, Path: "VAST/Ad[2]/Wrapper/Pricing", Message: `currency must be an ISO 4217 code, got "ABC"`},
+			{Severity: SeverityError, Path: "VAST/Ad[2]/Wrapper/Pricing", Message: "missing value"},
+		}))
+	})
+
+})
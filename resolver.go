@@ -0,0 +1,193 @@
+package vast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxWrapperDepth is the number of wrappers a Resolver follows when
+// MaxDepth is not set. The VAST spec recommends a limit of five.
+const DefaultMaxWrapperDepth = 5
+
+var (
+	// ErrWrapperLimit is returned when a wrapper chain exceeds the maximum depth
+	ErrWrapperLimit = errors.New("wrapper limit reached")
+	// ErrNoAdsAfterWrapper is returned when a wrapped tag contains no ads
+	ErrNoAdsAfterWrapper = errors.New("no ads after wrapper")
+	// ErrAdditionalWrapper is returned when a wrapped tag returns another
+	// wrapper although the outer wrapper disallows following it
+	ErrAdditionalWrapper = errors.New("additional wrapper not allowed")
+)
+
+// Resolver follows the VASTAdTagURI of every Wrapper ad until it reaches
+// the final InLine ads.
+type Resolver struct {
+	// The HTTP client used to fetch wrapped tags. Uses http.DefaultClient when nil.
+	Client *http.Client
+	// The maximum number of wrappers to follow for a single ad. Uses
+	// DefaultMaxWrapperDepth when zero.
+	MaxDepth int
+	// The total time allowed for resolving a document. No timeout when zero.
+	Timeout time.Duration
+}
+
+// ResolvedAd is an InLine ad along with the wrappers it was reached through,
+// or the Wrapper ad which could not be followed.
+type ResolvedAd struct {
+	// The ad containing the InLine element, or the failed Wrapper if Err is set
+	Ad *Ad
+	// The wrappers followed to reach the ad, outermost first. It includes
+	// the failed wrapper if Err is set.
+	Wrappers []*Wrapper
+	// The documents fetched while following the wrappers, outermost first.
+	// The last document is the one containing Ad.
+	Documents []*VAST
+	// The error which occurred while following the wrapper, if any
+	Err error
+	// The error code matching Err, to report through the error URIs
+	ErrorCode ErrorCode
+}
+
+// Resolve follows every wrapper in v and returns the resolved InLine ads in
+// document order. A wrapper which can't be followed doesn't prevent resolving
+// the other ads, its failure is returned in place of the ad. The error of the
+// first failure is also returned when no InLine ad could be resolved.
+//
+// The ads of a wrapped tag are resolved as allowed by the wrapper: all of
+// them if allowMultipleAds is set, otherwise only the first stand-alone ad.
+func (r *Resolver) Resolve(ctx context.Context, v *VAST) ([]ResolvedAd, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	res := r.resolve(ctx, v.Ads, nil, nil, true)
+	for _, ad := range res {
+		if ad.Err == nil {
+			return res, nil
+		}
+	}
+	if len(res) > 0 {
+		return res, res[0].Err
+	}
+	return res, nil
+}
+
+// resolve resolves the ads. Wrapper ads fail when follow is false.
+func (r *Resolver) resolve(ctx context.Context, ads []Ad, wrappers []*Wrapper, docs []*VAST, follow bool) []ResolvedAd {
+	var res []ResolvedAd
+	for i := range ads {
+		ad := &ads[i]
+		switch {
+		case ad.InLine != nil:
+			res = append(res, ResolvedAd{
+				Ad:        ad,
+				Wrappers:  wrappers,
+				Documents: docs,
+			})
+		case ad.Wrapper != nil:
+			// copy to avoid sharing backing arrays between sibling branches
+			chain := make([]*Wrapper, len(wrappers), len(wrappers)+1)
+			copy(chain, wrappers)
+			chain = append(chain, ad.Wrapper)
+
+			err := ErrAdditionalWrapper
+			if follow {
+				var ads []ResolvedAd
+				ads, err = r.follow(ctx, ad.Wrapper, chain, docs)
+				res = append(res, ads...)
+			}
+			if err != nil {
+				res = append(res, ResolvedAd{
+					Ad:        ad,
+					Wrappers:  chain,
+					Documents: docs,
+					Err:       err,
+					ErrorCode: ErrorCodeFor(err),
+				})
+			}
+		}
+	}
+	return res
+}
+
+// follow fetches the tag of w, the last wrapper of the chain, and resolves
+// its ads. Unless the wrapper allows multiple ads, only the first stand-alone
+// ad is resolved.
+func (r *Resolver) follow(ctx context.Context, w *Wrapper, chain []*Wrapper, docs []*VAST) ([]ResolvedAd, error) {
+	if len(chain) > r.maxDepth() {
+		return nil, ErrWrapperLimit
+	}
+
+	v, err := r.fetch(ctx, w.VASTAdTagURI.Name.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Ads) == 0 {
+		return nil, ErrNoAdsAfterWrapper
+	}
+
+	ads := v.Ads
+	if w.AllowMultipleAds == nil || !*w.AllowMultipleAds {
+		if ads = firstStandalone(v.Ads); len(ads) == 0 {
+			return nil, ErrNoAdsAfterWrapper
+		}
+	}
+
+	fetched := make([]*VAST, len(docs), len(docs)+1)
+	copy(fetched, docs)
+
+	follow := w.FollowAdditionalWrappers == nil || *w.FollowAdditionalWrappers
+	return r.resolve(ctx, ads, chain, append(fetched, v), follow), nil
+}
+
+// firstStandalone returns the first ad which is not part of a pod, sharing
+// the array of ads
+func firstStandalone(ads []Ad) []Ad {
+	for i := range ads {
+		if ads[i].Sequence == 0 {
+			return ads[i : i+1]
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) fetch(ctx context.Context, uri string) (*VAST, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %d from %s", resp.StatusCode, uri)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return FromXML(data)
+}
+
+func (r *Resolver) maxDepth() int {
+	if r.MaxDepth > 0 {
+		return r.MaxDepth
+	}
+	return DefaultMaxWrapperDepth
+}
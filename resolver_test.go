@@ -0,0 +1,196 @@
+package vast
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+	var server *httptest.Server
+	var subject *Resolver
+
+	wrapperTo := func(uri string) *VAST {
+		return &VAST{
+			Version: "3.0",
+			Ads: []Ad{{
+				ID: "w",
+				Wrapper: &Wrapper{
+					AdSystem:     &AdSystem{Name: "Wrapper"},
+					VASTAdTagURI: TagURI{Name: URI(uri)},
+				},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/inline", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadFile("testdata/vast_inline_linear.xml")
+			w.Write(b)
+		})
+		mux.HandleFunc("/wrapper", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := wrapperTo(server.URL + "/inline").MarshalXML()
+			w.Write(b)
+		})
+		mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := wrapperTo(server.URL + "/loop").MarshalXML()
+			w.Write(b)
+		})
+		inline := func(id string, seq int) Ad {
+			return Ad{ID: id, Sequence: seq, InLine: &InLine{AdSystem: &AdSystem{Name: "InLine"}}}
+		}
+		mux.HandleFunc("/pod", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := (&VAST{Version: "4.0", Ads: []Ad{
+				inline("p1", 1),
+				inline("s1", 0),
+				inline("p2", 2),
+				inline("s2", 0),
+			}}).MarshalXML()
+			w.Write(b)
+		})
+		mux.HandleFunc("/pod-only", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := (&VAST{Version: "4.0", Ads: []Ad{inline("p1", 1), inline("p2", 2)}}).MarshalXML()
+			w.Write(b)
+		})
+		mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<VAST version="3.0"></VAST>`))
+		})
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		})
+		server = httptest.NewServer(mux)
+		subject = &Resolver{Client: server.Client()}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should pass through inline ads", func() {
+		v, err := FromXML(mustReadFile("testdata/vast_inline_linear.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		ads, err := subject.Resolve(context.Background(), v)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].Ad).To(BeIdenticalTo(&v.Ads[0]))
+		Expect(ads[0].Wrappers).To(BeEmpty())
+	})
+
+	It("should follow wrapper chains", func() {
+		v := wrapperTo(server.URL + "/wrapper")
+		ads, err := subject.Resolve(context.Background(), v)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].Ad.ID).To(Equal("601364"))
+		Expect(ads[0].Ad.InLine).NotTo(BeNil())
+		Expect(ads[0].Wrappers).To(HaveLen(2))
+		Expect(ads[0].Wrappers[0]).To(BeIdenticalTo(v.Ads[0].Wrapper))
+		Expect(ads[0].Wrappers[1].VASTAdTagURI.Name).To(Equal(URI(server.URL + "/inline")))
+		Expect(ads[0].Documents).To(HaveLen(2))
+	})
+
+	It("should enforce max depth", func() {
+		subject.MaxDepth = 3
+		ads, err := subject.Resolve(context.Background(), wrapperTo(server.URL+"/loop"))
+		Expect(err).To(Equal(ErrWrapperLimit))
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].Err).To(Equal(ErrWrapperLimit))
+		Expect(ads[0].ErrorCode).To(Equal(ErrorCodeWrapperLimit))
+		Expect(ads[0].Ad.Wrapper).NotTo(BeNil())
+		Expect(ads[0].Wrappers).To(HaveLen(4))
+		Expect(ads[0].Documents).To(HaveLen(3))
+	})
+
+	It("should fail on empty responses", func() {
+		ads, err := subject.Resolve(context.Background(), wrapperTo(server.URL+"/empty"))
+		Expect(err).To(Equal(ErrNoAdsAfterWrapper))
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].ErrorCode).To(Equal(ErrorCodeNoAdsAfterWrapper))
+	})
+
+	It("should keep resolving the other ads", func() {
+		v := wrapperTo(server.URL + "/empty")
+		v.Ads = append(v.Ads, wrapperTo(server.URL+"/inline").Ads...)
+		ads, err := subject.Resolve(context.Background(), v)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ads).To(HaveLen(2))
+		Expect(ads[0].Ad).To(BeIdenticalTo(&v.Ads[0]))
+		Expect(ads[0].Err).To(Equal(ErrNoAdsAfterWrapper))
+		Expect(ads[0].ErrorCode).To(Equal(ErrorCodeNoAdsAfterWrapper))
+		Expect(ads[1].Err).NotTo(HaveOccurred())
+		Expect(ads[1].Ad.ID).To(Equal("601364"))
+	})
+
+	It("should not follow additional wrappers when disallowed", func() {
+		v := wrapperTo(server.URL + "/wrapper")
+		follow := false
+		v.Ads[0].Wrapper.FollowAdditionalWrappers = &follow
+		ads, err := subject.Resolve(context.Background(), v)
+		Expect(err).To(Equal(ErrAdditionalWrapper))
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].ErrorCode).To(Equal(ErrorCodeWrapper))
+		Expect(ads[0].Wrappers).To(HaveLen(2))
+		Expect(ads[0].Documents).To(HaveLen(1))
+	})
+
+	It("should resolve the first stand-alone ad unless multiple ads are allowed", func() {
+		ids := func(ads []ResolvedAd) []string {
+			var ids []string
+			for _, ad := range ads {
+				Expect(ad.Err).NotTo(HaveOccurred())
+				ids = append(ids, ad.Ad.ID)
+			}
+			return ids
+		}
+
+		ads, err := subject.Resolve(context.Background(), wrapperTo(server.URL+"/pod"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(ads)).To(Equal([]string{"s1"}))
+		Expect(ads[0].Documents).To(HaveLen(1))
+		Expect(ads[0].Ad).To(BeIdenticalTo(&ads[0].Documents[0].Ads[1]))
+
+		v := wrapperTo(server.URL + "/pod")
+		allow := true
+		v.Ads[0].Wrapper.AllowMultipleAds = &allow
+		ads, err = subject.Resolve(context.Background(), v)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(ads)).To(Equal([]string{"p1", "s1", "p2", "s2"}))
+	})
+
+	It("should fail on pods when multiple ads are not allowed", func() {
+		v := wrapperTo(server.URL + "/pod-only")
+		allow := false
+		v.Ads[0].Wrapper.AllowMultipleAds = &allow
+		ads, err := subject.Resolve(context.Background(), v)
+		Expect(err).To(Equal(ErrNoAdsAfterWrapper))
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].Ad).To(BeIdenticalTo(&v.Ads[0]))
+	})
+
+	It("should fail on bad status codes", func() {
+		_, err := subject.Resolve(context.Background(), wrapperTo(server.URL+"/missing"))
+		Expect(err).To(MatchError("unexpected response status 404 from " + server.URL + "/missing"))
+	})
+
+	It("should time out", func() {
+		subject.Timeout = 50 * time.Millisecond
+		ads, err := subject.Resolve(context.Background(), wrapperTo(server.URL+"/slow"))
+		Expect(err).To(HaveOccurred())
+		Expect(ads).To(HaveLen(1))
+		Expect(ads[0].ErrorCode).To(Equal(ErrorCodeWrapperTimeout))
+	})
+
+})
+
+func mustReadFile(name string) []byte {
+	b, err := ioutil.ReadFile(name)
+	Expect(err).NotTo(HaveOccurred())
+	return b
+}
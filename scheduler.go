@@ -0,0 +1,134 @@
+package vast
+
+import "time"
+
+// Scheduler determines which tracking URIs of a linear creative are due
+// during playback. It is fed with playhead updates and player state changes
+// and returns the URIs to request, firing every one-shot event only once.
+//
+// A Scheduler is not safe for concurrent use.
+type Scheduler struct {
+	events   []Tracking
+	duration time.Duration
+	fired    []bool
+	state    map[EventType]bool
+	finished bool
+}
+
+// NewScheduler creates a scheduler for the tracking events of a linear creative
+func NewScheduler(l *Linear) *Scheduler {
+	s := &Scheduler{
+		events: l.TrackingEvents,
+		fired:  make([]bool, len(l.TrackingEvents)),
+		state:  make(map[EventType]bool),
+	}
+	if l.Duration != nil {
+		s.duration = time.Duration(*l.Duration)
+	}
+	return s
+}
+
+// Update reports the current playhead position within the creative and
+// returns the URIs of the events that became due: creativeView and start on
+// the first update, then the quartiles, progress offsets and complete.
+func (s *Scheduler) Update(playhead time.Duration) []URI {
+	if s.finished {
+		return nil
+	}
+
+	var uris []URI
+	for i, t := range s.events {
+		if s.fired[i] || !s.due(&t, playhead) {
+			continue
+		}
+		s.fired[i] = true
+		uris = append(uris, t.URI)
+	}
+
+	if s.duration > 0 && playhead >= s.duration {
+		s.finished = true
+	}
+	return uris
+}
+
+// Trigger reports a player event and returns the URIs to request.
+//
+// State changes (pause/resume, mute/unmute, fullscreen/exitFullscreen and
+// playerExpand/playerCollapse) only fire when the state actually changes.
+// Skip and closeLinear fire once and end the playback, after which no more
+// events are fired. Other events fire every time they are triggered.
+func (s *Scheduler) Trigger(event EventType) []URI {
+	if s.finished {
+		return nil
+	}
+
+	event = event.Normalize()
+	switch event {
+	case EventPause, EventMute, EventFullscreen, EventPlayerExpand:
+		if s.state[event] {
+			return nil
+		}
+		s.state[event] = true
+	case EventResume, EventUnmute, EventExitFullscreen, EventPlayerCollapse:
+		on := stateEventOf[event]
+		if !s.state[on] {
+			return nil
+		}
+		s.state[on] = false
+	case EventSkip, EventCloseLinear, EventComplete:
+		s.finished = true
+	}
+
+	var uris []URI
+	for i, t := range s.events {
+		if t.Event.Normalize() != event || s.fired[i] {
+			continue
+		}
+		if isOneShotEvent(event) {
+			s.fired[i] = true
+		}
+		uris = append(uris, t.URI)
+	}
+	return uris
+}
+
+// Finished returns true once the creative has completed or was skipped or closed
+func (s *Scheduler) Finished() bool {
+	return s.finished
+}
+
+var stateEventOf = map[EventType]EventType{
+	EventResume:         EventPause,
+	EventUnmute:         EventMute,
+	EventExitFullscreen: EventFullscreen,
+	EventPlayerCollapse: EventPlayerExpand,
+}
+
+func isOneShotEvent(e EventType) bool {
+	switch e {
+	case EventCreativeView, EventStart, EventFirstQuartile, EventMidpoint, EventThirdQuartile,
+		EventComplete, EventProgress, EventSkip, EventCloseLinear, EventLoaded:
+		return true
+	}
+	return false
+}
+
+// due returns true if the playback driven event t is due at the playhead
+func (s *Scheduler) due(t *Tracking, playhead time.Duration) bool {
+	switch t.Event.Normalize() {
+	case EventCreativeView, EventStart:
+		return playhead >= 0
+	case EventFirstQuartile:
+		return s.duration > 0 && playhead >= s.duration/4
+	case EventMidpoint:
+		return s.duration > 0 && playhead >= s.duration/2
+	case EventThirdQuartile:
+		return s.duration > 0 && playhead >= s.duration*3/4
+	case EventComplete:
+		return s.duration > 0 && playhead >= s.duration
+	case EventProgress:
+		at, ok := t.ProgressAt(Duration(s.duration))
+		return ok && playhead >= at
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scheduler", func() {
+	var subject *Scheduler
+
+	BeforeEach(func() {
+		subject = NewScheduler(&Linear{
+			Duration: durationPtr(20 * time.Second),
+			TrackingEvents: []Tracking{
+				{Event: "creativeView", URI: "http://example.com/creativeView"},
+				{Event: "start", URI: "http://example.com/start"},
+				{Event: "firstQuartile", URI: "http://example.com/firstQuartile"},
+				{Event: "midpoint", URI: "http://example.com/midpoint"},
+				{Event: "thirdQuartile", URI: "http://example.com/thirdQuartile"},
+				{Event: "complete", URI: "http://example.com/complete"},
+				{Event: "progress", Offset: &Offset{Duration: durationPtr(3 * time.Second)}, URI: "http://example.com/progress/3s"},
+				{Event: "progress", Offset: &Offset{Percent: 0.6}, URI: "http://example.com/progress/60"},
+				{Event: "pause", URI: "http://example.com/pause"},
+				{Event: "resume", URI: "http://example.com/resume"},
+				{Event: "mute", URI: "http://example.com/mute"},
+				{Event: "unmute", URI: "http://example.com/unmute"},
+				{Event: "skip", URI: "http://example.com/skip"},
+			},
+		})
+	})
+
+	It("should fire playback events once", func() {
+		Expect(subject.Update(0)).To(Equal([]URI{"http://example.com/creativeView", "http://example.com/start"}))
+		Expect(subject.Update(time.Second)).To(BeEmpty())
+		Expect(subject.Update(3 * time.Second)).To(Equal([]URI{"http://example.com/progress/3s"}))
+		Expect(subject.Update(5 * time.Second)).To(Equal([]URI{"http://example.com/firstQuartile"}))
+		Expect(subject.Update(4 * time.Second)).To(BeEmpty())
+		Expect(subject.Update(12 * time.Second)).To(Equal([]URI{"http://example.com/midpoint", "http://example.com/progress/60"}))
+		Expect(subject.Update(20 * time.Second)).To(Equal([]URI{"http://example.com/thirdQuartile", "http://example.com/complete"}))
+		Expect(subject.Finished()).To(BeTrue())
+		Expect(subject.Update(21 * time.Second)).To(BeEmpty())
+	})
+
+	It("should fire state changes", func() {
+		Expect(subject.Trigger(EventResume)).To(BeEmpty())
+		Expect(subject.Trigger(EventPause)).To(Equal([]URI{"http://example.com/pause"}))
+		Expect(subject.Trigger(EventPause)).To(BeEmpty())
+		Expect(subject.Trigger("RESUME")).To(Equal([]URI{"http://example.com/resume"}))
+		Expect(subject.Trigger(EventPause)).To(Equal([]URI{"http://example.com/pause"}))
+		Expect(subject.Trigger(EventMute)).To(Equal([]URI{"http://example.com/mute"}))
+		Expect(subject.Trigger(EventUnmute)).To(Equal([]URI{"http://example.com/unmute"}))
+	})
+
+	It("should stop after skip", func() {
+		Expect(subject.Update(0)).To(HaveLen(2))
+		Expect(subject.Trigger(EventSkip)).To(Equal([]URI{"http://example.com/skip"}))
+		Expect(subject.Finished()).To(BeTrue())
+		Expect(subject.Trigger(EventSkip)).To(BeEmpty())
+		Expect(subject.Update(10 * time.Second)).To(BeEmpty())
+	})
+
+})
@@ -0,0 +1,623 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// Unknown holds the attributes and child elements of an element which are
+// not part of the model, as captured by FromXMLPreserving and
+// DecodePreserving. They are written back by MarshalXML and Encode.
+//
+// The namespace prefixes used by unknown content are declared along with
+// it, even if the original document declared them on an ancestor, e.g. the
+// VAST element.
+//
+// Only Ad, InLine, Wrapper, Creative, Linear, MediaFile, Companion and
+// NonLinear capture unknown content. Elements holding unknown content are
+// written without indentation.
+type Unknown struct {
+	// Attributes of the element, prefixed names are kept as is in Local,
+	// e.g. "vendor:id", along with the declarations of their prefixes, e.g.
+	// "xmlns:vendor"
+	Attrs []xml.Attr
+	// Child elements, in document order
+	Elements []UnknownElement
+}
+
+// UnknownElement is a child element which is not part of the model
+type UnknownElement struct {
+	// The name of the intermediate element holding the element, e.g.
+	// "MediaFiles" for a Mezzanine element of a Linear. Empty if the element
+	// is a direct child.
+	Container string
+	// The name of the element preceding the element in its parent, or in
+	// its container. The element is written back after the last element of
+	// that name, or first if empty.
+	After string
+	// The raw XML of the element
+	XML []byte
+}
+
+// FromXMLPreserving parses a document like FromXML, also capturing the
+// attributes and elements which are not part of the model, so that they are
+// written back when the document is marshalled.
+func FromXMLPreserving(xmlStr []byte) (*VAST, error) {
+	v, err := FromXML(xmlStr)
+	if err != nil {
+		return nil, err
+	}
+	captureUnknown(v, xmlStr)
+	return v, nil
+}
+
+// DecodePreserving parses a document from a reader like Decode, also
+// capturing the attributes and elements which are not part of the model.
+func DecodePreserving(r io.Reader) (*VAST, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	v, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	captureUnknown(v, data)
+	return v, nil
+}
+
+var unknownType = reflect.TypeOf((*Unknown)(nil))
+
+// captureFrame is an element being walked by captureUnknown
+type captureFrame struct {
+	// the struct the element is decoded into, invalid if not modelled
+	val reflect.Value
+	// the path of the intermediate elements within val, e.g. ["MediaFiles"]
+	container []string
+	// the name of the last child element, and the number of child elements
+	// per name for slices
+	last   string
+	counts map[string]int
+	// the namespace prefixes declared by the element
+	ns map[string]string
+}
+
+// lookupPrefix returns the declaration of a namespace prefix in scope
+func lookupPrefix(stack []*captureFrame, prefix string) (xml.Attr, bool) {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if uri, ok := stack[i].ns[prefix]; ok {
+			return xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: uri}, true
+		}
+	}
+	return xml.Attr{}, false
+}
+
+// declaredPrefixes returns the namespace prefixes declared by attrs
+func declaredPrefixes(attrs []xml.Attr) map[string]string {
+	var ns map[string]string
+	for _, a := range attrs {
+		if a.Name.Space == "xmlns" {
+			if ns == nil {
+				ns = make(map[string]string)
+			}
+			ns[a.Name.Local] = a.Value
+		}
+	}
+	return ns
+}
+
+// captureUnknown walks doc, which was decoded into v, and stores the
+// unknown attributes and elements of the core types
+func captureUnknown(v *VAST, doc []byte) {
+	var stack []*captureFrame
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		start := d.InputOffset()
+		tok, err := d.RawToken()
+		if err != nil {
+			return
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				stack = append(stack, &captureFrame{val: reflect.ValueOf(v).Elem(), counts: make(map[string]int), ns: declaredPrefixes(t.Attr)})
+				continue
+			}
+
+			p := stack[len(stack)-1]
+			child := &captureFrame{counts: make(map[string]int), ns: declaredPrefixes(t.Attr)}
+			if p.val.IsValid() {
+				path := append(append([]string(nil), p.container...), t.Name.Local)
+				f, exact, ok := elementField(p.val.Type(), path)
+				switch {
+				case !ok:
+					// unknown element, capture it whole
+					if !skipElement(d) {
+						return
+					}
+					if u := unknownOf(p.val); u != nil {
+						raw := doc[start:d.InputOffset()]
+						u.Elements = append(u.Elements, UnknownElement{
+							Container: strings.Join(p.container, ">"),
+							After:     p.last,
+							XML:       declarePrefixes(raw, stack),
+						})
+					}
+					p.last = t.Name.Local
+					continue
+				case !exact:
+					child.val, child.container, child.counts = p.val, path, p.counts
+				default:
+					child.val = fieldElement(p.val.Field(f), p.counts, t.Name.Local)
+				}
+			}
+			p.last = t.Name.Local
+			stack = append(stack, child)
+			if child.val.IsValid() && len(child.container) == 0 {
+				captureAttrs(child.val, t.Attr, stack)
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// fieldElement returns the struct decoded from the next element named name
+// into the field, or an invalid value
+func fieldElement(field reflect.Value, counts map[string]int, name string) reflect.Value {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		i := counts[name]
+		counts[name]++
+		if i >= field.Len() {
+			return reflect.Value{}
+		}
+		field = field.Index(i)
+	}
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return reflect.Value{}
+		}
+		field = field.Elem()
+	}
+	if field.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return field
+}
+
+// captureAttrs stores the attributes of the element decoded into val which
+// are not part of the model, along with the declarations of their prefixes.
+// The stack ends with the frame of the element.
+func captureAttrs(val reflect.Value, attrs []xml.Attr, stack []*captureFrame) {
+	known := attrFields(val.Type())
+	var captured []xml.Attr
+	used := make(map[string]bool)
+	for _, a := range attrs {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+		if a.Name.Space == "" && known[a.Name.Local] {
+			continue
+		}
+		if p := a.Name.Space; p != "" && p != "xml" {
+			if !used[p] {
+				if decl, ok := lookupPrefix(stack, p); ok {
+					captured = append(captured, decl)
+				}
+			}
+			used[p] = true
+			a.Name = xml.Name{Local: p + ":" + a.Name.Local}
+		}
+		captured = append(captured, a)
+	}
+	if len(captured) == 0 {
+		return
+	}
+	if u := unknownOf(val); u != nil {
+		u.Attrs = append(u.Attrs, captured...)
+	}
+}
+
+// declarePrefixes returns a copy of raw, the XML of an element, declaring
+// on the element the prefixes it uses which were declared by the stack
+func declarePrefixes(raw []byte, stack []*captureFrame) []byte {
+	var decls []byte
+	seen := make(map[string]bool)
+	var scopes []map[string]string
+	d := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			scopes = append(scopes, declaredPrefixes(t.Attr))
+			names := []xml.Name{t.Name}
+			for _, a := range t.Attr {
+				names = append(names, a.Name)
+			}
+			for _, n := range names {
+				p := n.Space
+				if p == "" || p == "xml" || p == "xmlns" || seen[p] || declaredIn(scopes, p) {
+					continue
+				}
+				seen[p] = true
+				if decl, ok := lookupPrefix(stack, p); ok {
+					decls = append(decls, ` `+decl.Name.Local+`="`...)
+					buf := new(bytes.Buffer)
+					_ = xml.EscapeText(buf, []byte(decl.Value))
+					decls = append(append(decls, buf.Bytes()...), '"')
+				}
+			}
+		case xml.EndElement:
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	// insert the declarations after the element name
+	name := elementQName(raw)
+	out := make([]byte, 0, len(raw)+len(decls))
+	out = append(out, raw[:1+len(name)]...)
+	out = append(out, decls...)
+	return append(out, raw[1+len(name):]...)
+}
+
+// declaredIn returns true if the prefix is declared in one of the scopes
+func declaredIn(scopes []map[string]string, prefix string) bool {
+	for _, ns := range scopes {
+		if _, ok := ns[prefix]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// elementQName returns the qualified name of the element encoded in raw
+func elementQName(raw []byte) string {
+	tok, err := xml.NewDecoder(bytes.NewReader(raw)).RawToken()
+	if err != nil {
+		return ""
+	}
+	start, ok := tok.(xml.StartElement)
+	switch {
+	case !ok:
+		return ""
+	case start.Name.Space != "":
+		return start.Name.Space + ":" + start.Name.Local
+	}
+	return start.Name.Local
+}
+
+// unknownOf returns the unknown content of a core type, allocating it if
+// needed, or nil for other types
+func unknownOf(val reflect.Value) *Unknown {
+	f := val.FieldByName("Unknown")
+	if !f.IsValid() || f.Type() != unknownType || !f.CanSet() {
+		return nil
+	}
+	if f.IsNil() {
+		f.Set(reflect.ValueOf(new(Unknown)))
+	}
+	return f.Interface().(*Unknown)
+}
+
+// elementField returns the index of the field of t decoded from the
+// element at path, and whether path designates the field itself rather than
+// an intermediate element
+func elementField(t reflect.Type, path []string) (index int, exact, ok bool) {
+	for i := 0; i < t.NumField(); i++ {
+		names, ok := fieldPath(t.Field(i))
+		if !ok || len(names) < len(path) {
+			continue
+		}
+		match := true
+		for j := range path {
+			match = match && names[j] == path[j]
+		}
+		if match {
+			return i, len(names) == len(path), true
+		}
+	}
+	return 0, false, false
+}
+
+// fieldPath returns the element names a field is decoded from, or false if
+// the field is not decoded from an element
+func fieldPath(f reflect.StructField) ([]string, bool) {
+	if f.PkgPath != "" || f.Name == "XMLName" {
+		return nil, false
+	}
+	tag := f.Tag.Get("xml")
+	if tag == "-" {
+		return nil, false
+	}
+	opts := strings.Split(tag, ",")
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "attr", "chardata", "cdata", "innerxml", "comment", "any":
+			return nil, false
+		}
+	}
+	if opts[0] == "" {
+		return []string{f.Name}, true
+	}
+	return strings.Split(opts[0], ">"), true
+}
+
+// attrFields returns the names of the attributes modelled by t
+func attrFields(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		opts := strings.Split(t.Field(i).Tag.Get("xml"), ",")
+		for _, opt := range opts[1:] {
+			if opt == "attr" {
+				names[opts[0]] = true
+			}
+		}
+	}
+	return names
+}
+
+// skipElement consumes the rest of the element just started
+func skipElement(d *xml.Decoder) bool {
+	for depth := 1; depth > 0; {
+		tok, err := d.RawToken()
+		if err != nil {
+			return false
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return true
+}
+
+// rawElement is an element written with its attributes and raw content
+type rawElement struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner []byte     `xml:",innerxml"`
+}
+
+// encodeUnknown encodes v, the plain version of a core type, as the element
+// start along with the unknown content
+func encodeUnknown(e *xml.Encoder, start xml.StartElement, v interface{}, u *Unknown) error {
+	if u == nil || (len(u.Attrs) == 0 && len(u.Elements) == 0) {
+		return e.EncodeElement(v, start)
+	}
+
+	buf := new(bytes.Buffer)
+	enc := xml.NewEncoder(buf)
+	if err := enc.EncodeElement(v, start); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	el, err := parseRawElement(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	inner, children := el.inner, el.children
+	for _, ue := range u.Elements {
+		name := elementName(ue.XML)
+		if ue.Container == "" {
+			inner, children = insertChild(inner, children, ue.After, name, ue.XML)
+			continue
+		}
+
+		i := indexOfChild(children, ue.Container)
+		if i < 0 {
+			// the container was omitted, write it at the end
+			raw := "<" + ue.Container + "></" + ue.Container + ">"
+			children = append(children, span{name: ue.Container, start: len(inner), end: len(inner) + len(raw)})
+			inner = append(append([]byte(nil), inner...), raw...)
+			i = len(children) - 1
+		}
+		c := children[i]
+		cel, err := parseRawElement(inner[c.start:c.end])
+		if err != nil {
+			return err
+		}
+		cinner, _ := insertChild(cel.inner, cel.children, ue.After, name, ue.XML)
+		raw := append(append([]byte(nil), inner[c.start:c.start+cel.innerStart]...), cinner...)
+		raw = append(raw, inner[c.start+cel.innerEnd:c.end]...)
+		inner, children = replaceChild(inner, children, i, raw)
+	}
+
+	return e.EncodeElement(rawElement{
+		Attrs: append(el.attrs, u.Attrs...),
+		Inner: inner,
+	}, start)
+}
+
+// insertChild inserts raw after the last child named after, at the start of
+// inner if after is empty, or at the end if there is no such child
+func insertChild(inner []byte, children []span, after, name string, raw []byte) ([]byte, []span) {
+	pos, at := len(inner), len(children)
+	switch {
+	case after == "":
+		pos, at = 0, 0
+	default:
+		for i := len(children) - 1; i >= 0; i-- {
+			if children[i].name == after {
+				pos, at = children[i].end, i+1
+				break
+			}
+		}
+	}
+
+	out := append(append(append([]byte(nil), inner[:pos]...), raw...), inner[pos:]...)
+	spans := make([]span, 0, len(children)+1)
+	spans = append(spans, children[:at]...)
+	spans = append(spans, span{name: name, start: pos, end: pos + len(raw)})
+	for _, c := range children[at:] {
+		spans = append(spans, span{name: c.name, start: c.start + len(raw), end: c.end + len(raw)})
+	}
+	return out, spans
+}
+
+// replaceChild replaces the i-th child of inner with raw
+func replaceChild(inner []byte, children []span, i int, raw []byte) ([]byte, []span) {
+	c := children[i]
+	out := append(append(append([]byte(nil), inner[:c.start]...), raw...), inner[c.end:]...)
+	delta := len(raw) - (c.end - c.start)
+	spans := append([]span(nil), children...)
+	spans[i].end += delta
+	for j := i + 1; j < len(spans); j++ {
+		spans[j].start += delta
+		spans[j].end += delta
+	}
+	return out, spans
+}
+
+// elementName returns the local name of the element encoded in raw
+func elementName(raw []byte) string {
+	tok, err := xml.NewDecoder(bytes.NewReader(raw)).RawToken()
+	if err != nil {
+		return ""
+	}
+	if start, ok := tok.(xml.StartElement); ok {
+		return start.Name.Local
+	}
+	return ""
+}
+
+// span locates a child element within the content of its parent
+type span struct {
+	name       string
+	start, end int
+}
+
+// parsedElement is an encoded element split into parts
+type parsedElement struct {
+	attrs                []xml.Attr
+	innerStart, innerEnd int
+	inner                []byte
+	children             []span
+}
+
+// parseRawElement splits the XML of an element into its attributes, its
+// content and the spans of its children within the content
+func parseRawElement(b []byte) (*parsedElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	tok, err := d.RawToken()
+	if err != nil {
+		return nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, &xml.SyntaxError{Msg: "expected element", Line: 1}
+	}
+
+	el := &parsedElement{innerStart: int(d.InputOffset())}
+	for _, a := range start.Attr {
+		if a.Name.Space != "" {
+			a.Name = xml.Name{Local: a.Name.Space + ":" + a.Name.Local}
+		}
+		el.attrs = append(el.attrs, a)
+	}
+
+	el.innerEnd = el.innerStart
+	for depth := 1; depth > 0; {
+		offset := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 {
+				el.children = append(el.children, span{name: t.Name.Local, start: offset - el.innerStart})
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			switch depth {
+			case 1:
+				el.children[len(el.children)-1].end = int(d.InputOffset()) - el.innerStart
+			case 0:
+				el.innerEnd = offset
+			}
+		}
+	}
+	el.inner = b[el.innerStart:el.innerEnd]
+	return el, nil
+}
+
+// indexOfChild returns the index of the first child named name, or -1
+func indexOfChild(children []span, name string) int {
+	for i, c := range children {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (ad *Ad) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Ad
+	return encodeUnknown(e, start, (*plain)(ad), ad.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (in *InLine) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain InLine
+	return encodeUnknown(e, start, (*plain)(in), in.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (w *Wrapper) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Wrapper
+	return encodeUnknown(e, start, (*plain)(w), w.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (c *Creative) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Creative
+	return encodeUnknown(e, start, (*plain)(c), c.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (l *Linear) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Linear
+	return encodeUnknown(e, start, (*plain)(l), l.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (mf *MediaFile) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain MediaFile
+	return encodeUnknown(e, start, (*plain)(mf), mf.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (c *Companion) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Companion
+	return encodeUnknown(e, start, (*plain)(c), c.Unknown)
+}
+
+// MarshalXML implements the xml.Marshaler interface, writing back the
+// unknown attributes and elements.
+func (nl *NonLinear) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain NonLinear
+	return encodeUnknown(e, start, (*plain)(nl), nl.Unknown)
+}
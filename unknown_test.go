@@ -0,0 +1,128 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Unknown", func() {
+	const doc = `<VAST version="3.0" xmlns:vendor="http://example.com/vendor">` +
+		`<Ad id="1" vendor:tag="x"><Custom>a</Custom><vendor:Data>d</vendor:Data><InLine>` +
+		`<AdSystem><![CDATA[sys]]></AdSystem><AdTitle><![CDATA[title]]></AdTitle><AdServingId>srv</AdServingId>` +
+		`<Impression><![CDATA[http://example.com/imp1]]></Impression><Impression><![CDATA[http://example.com/imp2]]></Impression>` +
+		`<Creatives><Creative id="c1"><Vendor><Data key="k"/></Vendor><Linear>` +
+		`<Duration>00:00:10</Duration><TrackingEvents></TrackingEvents>` +
+		`<MediaFiles><MediaFile delivery="progressive" type="video/mp4" width="640" height="360" fileSize="1000"><![CDATA[http://example.com/video.mp4]]></MediaFile>` +
+		`<Mezzanine delivery="progressive" type="video/mp4"><![CDATA[http://example.com/mezzanine.mp4]]></Mezzanine></MediaFiles>` +
+		`</Linear></Creative>` +
+		`<Creative id="c2"><CompanionAds><Companion width="300" height="250" assetWidth="0" assetHeight="0" expandedWidth="0" expandedHeight="0" pxratio="2">` +
+		`<StaticResource creativeType="image/png"><![CDATA[http://example.com/banner.png]]></StaticResource><TrackingEvents></TrackingEvents></Companion></CompanionAds></Creative>` +
+		`<Creative id="c3"><NonLinearAds><TrackingEvents></TrackingEvents><NonLinear width="300" height="50" expandedWidth="0" expandedHeight="0"><AltText>text</AltText></NonLinear></NonLinearAds></Creative>` +
+		`</Creatives><Expires>60</Expires><Ext4>e</Ext4></InLine></Ad>` +
+		`<Ad id="2"><Wrapper><AdSystem><![CDATA[sys]]></AdSystem><VASTAdTagURI><![CDATA[http://example.com/vast.xml]]></VASTAdTagURI><BlockedAdCategories>IAB25</BlockedAdCategories>` +
+		`<Impression><![CDATA[http://example.com/imp]]></Impression><Creatives></Creatives></Wrapper></Ad>` +
+		`</VAST>`
+
+	It("should be dropped by default", func() {
+		v, err := FromXML([]byte(doc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0].Unknown).To(BeNil())
+		Expect(v.Ads[0].InLine.Unknown).To(BeNil())
+	})
+
+	It("should be captured", func() {
+		v, err := FromXMLPreserving([]byte(doc))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(v.Ads[0].Unknown).To(Equal(&Unknown{
+			Attrs: []xml.Attr{
+				{Name: xml.Name{Local: "xmlns:vendor"}, Value: "http://example.com/vendor"},
+				{Name: xml.Name{Local: "vendor:tag"}, Value: "x"},
+			},
+			Elements: []UnknownElement{
+				{XML: []byte("<Custom>a</Custom>")},
+				{After: "Custom", XML: []byte(`<vendor:Data xmlns:vendor="http://example.com/vendor">d</vendor:Data>`)},
+			},
+		}))
+		Expect(v.Ads[0].InLine.Unknown).To(Equal(&Unknown{
+			Elements: []UnknownElement{{After: "Expires", XML: []byte("<Ext4>e</Ext4>")}},
+		}))
+
+		creatives := v.Ads[0].InLine.Creatives
+		Expect(creatives[0].Unknown.Elements).To(Equal([]UnknownElement{{XML: []byte(`<Vendor><Data key="k"/></Vendor>`)}}))
+		Expect(creatives[0].Linear.Unknown.Elements).To(Equal([]UnknownElement{{
+			Container: "MediaFiles",
+			After:     "MediaFile",
+			XML:       []byte(`<Mezzanine delivery="progressive" type="video/mp4"><![CDATA[http://example.com/mezzanine.mp4]]></Mezzanine>`),
+		}}))
+		Expect(creatives[0].Linear.MediaFiles[0].Unknown.Attrs).To(Equal([]xml.Attr{{Name: xml.Name{Local: "fileSize"}, Value: "1000"}}))
+		Expect(creatives[1].CompanionAds.Companions[0].Unknown.Attrs).To(Equal([]xml.Attr{{Name: xml.Name{Local: "pxratio"}, Value: "2"}}))
+		Expect(creatives[2].NonLinearAds.NonLinears[0].Unknown.Elements).To(Equal([]UnknownElement{{XML: []byte("<AltText>text</AltText>")}}))
+		Expect(v.Ads[1].Wrapper.Unknown.Elements).To(Equal([]UnknownElement{{After: "VASTAdTagURI", XML: []byte("<BlockedAdCategories>IAB25</BlockedAdCategories>")}}))
+	})
+
+	It("should be written back in position", func() {
+		v, err := FromXMLPreserving([]byte(doc))
+		Expect(err).NotTo(HaveOccurred())
+
+		b, err := v.MarshalXML()
+		Expect(err).NotTo(HaveOccurred())
+		// the prefix is declared where it is used
+		Expect(string(b)).To(Equal(xml.Header[:len(xml.Header)-1] + strings.NewReplacer(
+			` xmlns:vendor="http://example.com/vendor">`, `>`,
+			`<Ad id="1" vendor:tag="x">`, `<Ad id="1" xmlns:vendor="http://example.com/vendor" vendor:tag="x">`,
+			`<vendor:Data>`, `<vendor:Data xmlns:vendor="http://example.com/vendor">`,
+		).Replace(doc)))
+
+		// the output still declares the prefixes
+		var names []xml.Name
+		d := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				Expect(err).To(Equal(io.EOF))
+				break
+			}
+			if start, ok := tok.(xml.StartElement); ok {
+				names = append(names, start.Name)
+				for _, a := range start.Attr {
+					names = append(names, a.Name)
+				}
+			}
+		}
+		Expect(names).To(ContainElement(xml.Name{Space: "http://example.com/vendor", Local: "tag"}))
+		Expect(names).To(ContainElement(xml.Name{Space: "http://example.com/vendor", Local: "Data"}))
+	})
+
+	It("should be preserved by the codec", func() {
+		indented := strings.Replace(doc, "<Creatives>", "\n\t<Creatives>\n\t", -1)
+		v, err := DecodePreserving(strings.NewReader(indented))
+		Expect(err).NotTo(HaveOccurred())
+
+		buf := new(bytes.Buffer)
+		Expect(Encode(buf, v)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`<Ad id="1" xmlns:vendor="http://example.com/vendor" vendor:tag="x"><Custom>a</Custom><vendor:Data xmlns:vendor="http://example.com/vendor">d</vendor:Data><InLine>`))
+		Expect(buf.String()).To(ContainSubstring(`</MediaFile><Mezzanine delivery="progressive" type="video/mp4"><![CDATA[http://example.com/mezzanine.mp4]]></Mezzanine></MediaFiles>`))
+		Expect(buf.String()).To(ContainSubstring(`<VASTAdTagURI><![CDATA[http://example.com/vast.xml]]></VASTAdTagURI><BlockedAdCategories>IAB25</BlockedAdCategories>`))
+	})
+
+	It("should keep the whitespace inside captured elements", func() {
+		spaced := strings.Replace(doc, "<Custom>a</Custom>", "<Custom>a\n\tb</Custom>", 1)
+		v, err := FromXMLPreserving([]byte(spaced))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Ads[0].Unknown.Elements[0].XML).To(Equal([]byte("<Custom>a\n\tb</Custom>")))
+	})
+
+	It("should recreate omitted containers", func() {
+		l := &Linear{Unknown: &Unknown{Elements: []UnknownElement{{Container: "MediaFiles", XML: []byte("<Mezzanine>m</Mezzanine>")}}}}
+		b, err := xml.Marshal(l)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("<Linear><TrackingEvents></TrackingEvents><MediaFiles><Mezzanine>m</Mezzanine></MediaFiles></Linear>"))
+	})
+
+})
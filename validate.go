@@ -0,0 +1,298 @@
+package vast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity indicates how serious a validation issue is
+type Severity int
+
+// Issue severities
+const (
+	// SeverityWarning marks a deviation from the recommendations of the spec
+	SeverityWarning Severity = iota
+	// SeverityError marks a violation of the spec
+	SeverityError
+)
+
+// String implements Stringer interface
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a spec violation reported by Validate
+type Issue struct {
+	Severity Severity
+	// The XPath-like location of the offending element, e.g.
+	// "VAST/Ad[1]/InLine/Creatives/Creative[2]/Linear".
+	Path    string
+	Message string
+}
+
+// String implements Stringer interface
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Path, i.Message)
+}
+
+// Validate checks v against the semantic rules of the given VAST version,
+// which defaults to v.Version when empty. It returns the issues found, in
+// document order.
+func Validate(v *VAST, version string) []Issue {
+	if version == "" {
+		version = v.Version
+	}
+
+	vd := &validator{major: majorVersion(version)}
+	vd.vast(v)
+	return vd.issues
+}
+
+type validator struct {
+	major  int
+	issues []Issue
+}
+
+func (vd *validator) add(sev Severity, path, format string, args ...interface{}) {
+	vd.issues = append(vd.issues, Issue{
+		Severity: sev,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (vd *validator) vast(v *VAST) {
+	if v.Version == "" {
+		vd.add(SeverityError, "VAST", "missing version attribute")
+	}
+	for i := range v.Ads {
+		vd.ad(&v.Ads[i], fmt.Sprintf("VAST/Ad[%d]", i+1))
+	}
+}
+
+func (vd *validator) ad(ad *Ad, path string) {
+	switch {
+	case ad.InLine != nil && ad.Wrapper != nil:
+		vd.add(SeverityError, path, "must contain either InLine or Wrapper, not both")
+	case ad.InLine != nil:
+		vd.inline(ad.InLine, path+"/InLine")
+	case ad.Wrapper != nil:
+		vd.wrapper(ad.Wrapper, path+"/Wrapper")
+	default:
+		vd.add(SeverityError, path, "must contain either InLine or Wrapper")
+	}
+	if ad.Sequence < 0 {
+		vd.add(SeverityError, path, "sequence must be a positive number")
+	}
+}
+
+func (vd *validator) inline(in *InLine, path string) {
+	if in.AdSystem == nil {
+		vd.add(SeverityError, path, "missing AdSystem")
+	}
+	if in.AdTitle == nil {
+		vd.add(SeverityError, path, "missing AdTitle")
+	}
+	if len(in.Impressions) == 0 {
+		vd.add(SeverityError, path, "missing Impression")
+	}
+	if in.Pricing != nil {
+		vd.pricing(in.Pricing, path+"/Pricing")
+	}
+	if vd.major >= 4 && in.AdServingID == "" {
+		vd.add(SeverityWarning, path, "missing AdServingId")
+	}
+	if len(in.Creatives) == 0 {
+		vd.add(SeverityError, path, "missing Creatives")
+	}
+	for i := range in.Creatives {
+		vd.creative(&in.Creatives[i], fmt.Sprintf("%s/Creatives/Creative[%d]", path, i+1))
+	}
+}
+
+func (vd *validator) wrapper(w *Wrapper, path string) {
+	if w.AdSystem == nil {
+		vd.add(SeverityError, path, "missing AdSystem")
+	}
+	if w.VASTAdTagURI.Name == "" {
+		vd.add(SeverityError, path, "missing VASTAdTagURI")
+	}
+	if len(w.Impressions) == 0 {
+		vd.add(SeverityError, path, "missing Impression")
+	}
+	if w.Pricing != nil {
+		vd.pricing(w.Pricing, path+"/Pricing")
+	}
+	if vd.major < 4 {
+		for _, attr := range []struct {
+			name string
+			set  bool
+		}{
+			{"followAdditionalWrappers", w.FollowAdditionalWrappers != nil},
+			{"allowMultipleAds", w.AllowMultipleAds != nil},
+			{"fallbackOnNoAd", w.FallbackOnNoAd != nil},
+		} {
+			if attr.set {
+				vd.add(SeverityError, path, "%s attribute requires VAST 4", attr.name)
+			}
+		}
+	}
+	for i := range w.Creatives {
+		c := &w.Creatives[i]
+		cpath := fmt.Sprintf("%s/Creatives/Creative[%d]", path, i+1)
+		if c.Linear != nil {
+			vd.trackingEvents(c.Linear.TrackingEvents, cpath+"/Linear")
+			vd.icons(c.Linear.Icons, cpath+"/Linear")
+		}
+		if c.NonLinearAds != nil {
+			vd.trackingEvents(c.NonLinearAds.TrackingEvents, cpath+"/NonLinearAds")
+		}
+	}
+}
+
+func (vd *validator) pricing(p *Pricing, path string) {
+	switch {
+	case p.Model == "":
+		vd.add(SeverityError, path, "missing model attribute")
+	case !p.Model.IsKnown():
+		vd.add(SeverityError, path, "model must be one of cpc, cpm, cpe or cpv, got %q", p.Model)
+	}
+	switch {
+	case p.Currency == "":
+		vd.add(SeverityError, path, "missing currency attribute")
+	case !isCurrency(p.Currency):
+		vd.add(SeverityError, path, "currency must be an ISO 4217 code, got %q", p.Currency)
+	case strings.ToUpper(p.Currency) != p.Currency:
+		vd.add(SeverityWarning, path, "currency %q should be spelled %q", p.Currency, strings.ToUpper(p.Currency))
+	}
+	if strings.TrimSpace(p.Value) == "" {
+		vd.add(SeverityError, path, "missing value")
+	}
+}
+
+func (vd *validator) creative(c *Creative, path string) {
+	n := 0
+	if c.Linear != nil {
+		n++
+		vd.linear(c.Linear, path+"/Linear")
+	}
+	if c.CompanionAds != nil {
+		n++
+		vd.companionAds(c.CompanionAds, path+"/CompanionAds")
+	}
+	if c.NonLinearAds != nil {
+		n++
+		vd.trackingEvents(c.NonLinearAds.TrackingEvents, path+"/NonLinearAds")
+	}
+	if n != 1 {
+		vd.add(SeverityError, path, "must contain exactly one of Linear, CompanionAds or NonLinearAds")
+	}
+	if vd.major >= 4 && len(c.UniversalAdIDs) == 0 {
+		vd.add(SeverityError, path, "missing UniversalAdId")
+	}
+}
+
+func (vd *validator) linear(l *Linear, path string) {
+	if l.Duration == nil {
+		vd.add(SeverityError, path, "missing Duration")
+	}
+	if len(l.MediaFiles) == 0 {
+		vd.add(SeverityError, path, "missing MediaFiles")
+	}
+	vd.trackingEvents(l.TrackingEvents, path)
+	vd.icons(l.Icons, path)
+	for i := range l.MediaFiles {
+		vd.mediaFile(&l.MediaFiles[i], fmt.Sprintf("%s/MediaFiles/MediaFile[%d]", path, i+1))
+	}
+}
+
+func (vd *validator) mediaFile(mf *MediaFile, path string) {
+	if mf.Delivery != "progressive" && mf.Delivery != "streaming" {
+		vd.add(SeverityError, path, "delivery must be either progressive or streaming, got %q", mf.Delivery)
+	}
+	if mf.Type == "" {
+		vd.add(SeverityError, path, "missing type attribute")
+	}
+	if mf.URI == "" {
+		vd.add(SeverityError, path, "missing URI")
+	}
+	if mf.Bitrate != 0 && (mf.MinBitrate != 0 || mf.MaxBitrate != 0) {
+		vd.add(SeverityError, path, "bitrate must not be combined with minBitrate or maxBitrate")
+	}
+	if (mf.MinBitrate != 0) != (mf.MaxBitrate != 0) {
+		vd.add(SeverityError, path, "minBitrate and maxBitrate must be supplied together")
+	}
+	if mf.MinBitrate > mf.MaxBitrate && mf.MaxBitrate != 0 {
+		vd.add(SeverityError, path, "minBitrate must not exceed maxBitrate")
+	}
+}
+
+func (vd *validator) companionAds(ca *CompanionAds, path string) {
+	switch ca.Required {
+	case "", "all", "any", "none":
+	default:
+		vd.add(SeverityError, path, "required must be one of all, any or none, got %q", ca.Required)
+	}
+	for i := range ca.Companions {
+		c := &ca.Companions[i]
+		cpath := fmt.Sprintf("%s/Companion[%d]", path, i+1)
+		if c.StaticResource == nil && c.IFrameResource == "" && c.HTMLResource == nil {
+			vd.add(SeverityError, cpath, "missing StaticResource, IFrameResource or HTMLResource")
+		}
+		vd.trackingEvents(c.TrackingEvents, cpath)
+	}
+}
+
+func (vd *validator) trackingEvents(events []Tracking, path string) {
+	for i, t := range events {
+		tpath := fmt.Sprintf("%s/TrackingEvents/Tracking[%d]", path, i+1)
+		switch {
+		case t.Event == "":
+			vd.add(SeverityError, tpath, "missing event attribute")
+		case !t.Event.IsKnown():
+			vd.add(SeverityWarning, tpath, "unknown event %q", t.Event)
+		case t.Event.Normalize() != t.Event:
+			vd.add(SeverityWarning, tpath, "event %q should be spelled %q", t.Event, t.Event.Normalize())
+		}
+		if t.Event.Normalize() == EventProgress && t.Offset == nil {
+			vd.add(SeverityError, tpath, "progress event requires an offset")
+		}
+		if t.URI == "" {
+			vd.add(SeverityWarning, tpath, "missing URI")
+		}
+	}
+}
+
+func (vd *validator) icons(icons *Icons, path string) {
+	if icons == nil {
+		return
+	}
+	for i, icon := range icons.Icons {
+		ipath := fmt.Sprintf("%s/Icons/Icon[%d]", path, i+1)
+		if icon.Program == "" && vd.major < 4 {
+			vd.add(SeverityError, ipath, "missing program attribute")
+		}
+		if a := icon.XPosition.Align; a != "" && a != IconAlignLeft && a != IconAlignRight {
+			vd.add(SeverityError, ipath, "invalid xPosition %q", a)
+		}
+		if a := icon.YPosition.Align; a != "" && a != IconAlignTop && a != IconAlignBottom {
+			vd.add(SeverityError, ipath, "invalid yPosition %q", a)
+		}
+		if icon.StaticResource == nil && icon.IFrameResource == "" && icon.HTMLResource == nil {
+			vd.add(SeverityError, ipath, "missing StaticResource, IFrameResource or HTMLResource")
+		}
+	}
+}
+
+// majorVersion returns the major number of a version string such as "4.1"
+func majorVersion(version string) int {
+	if i := strings.IndexByte(version, '.'); i > -1 {
+		version = version[:i]
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(version))
+	return n
+}
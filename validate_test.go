@@ -0,0 +1,96 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+
+	DescribeTable("valid fixtures",
+		func(fixture string) {
+			v, err := FromXML(mustReadFile(fixture))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Validate(v, "")).To(BeEmpty())
+		},
+		Entry("inline linear", "testdata/vast_inline_linear.xml"),
+		Entry("inline nonlinear", "testdata/vast_inline_nonlinear.xml"),
+		Entry("wrapper linear", "testdata/vast_wrapper_linear_1.xml"),
+		Entry("wrapper nonlinear", "testdata/vast_wrapper_nonlinear_1.xml"),
+		Entry("VAST 4", "testdata/vast4_inline_linear.xml"),
+	)
+
+	It("should report issues with paths", func() {
+		inline := func(c Creative) *InLine {
+			return &InLine{
+				AdSystem:    &AdSystem{Name: "test"},
+				AdTitle:     &AdTitle{Name: "test"},
+				Impressions: []Impression{{URI: "http://example.com/imp"}},
+				Creatives:   []Creative{c},
+			}
+		}
+		v := &VAST{
+			Version: "3.0",
+			Ads: []Ad{
+				{},
+				{InLine: &InLine{}, Wrapper: &Wrapper{}},
+				{InLine: inline(Creative{Linear: &Linear{
+					TrackingEvents: []Tracking{{Event: "progress", URI: "http://example.com/progress"}},
+					Icons: &Icons{Icons: []Icon{{
+						Program:        "AdChoices",
+						XPosition:      IconPosition{Align: "middle"},
+						YPosition:      IconPosition{Align: IconAlignTop},
+						StaticResource: &StaticResource{URI: "http://example.com/icon.png"},
+					}}},
+				}})},
+				{InLine: inline(Creative{Linear: &Linear{
+					Duration: durationPtr(time.Second),
+					MediaFiles: []MediaFile{
+						{Delivery: "progressive", Type: "video/mp4", Bitrate: 500, MinBitrate: 300, MaxBitrate: 800, URI: "http://example.com/a.mp4"},
+					},
+				}})},
+			},
+		}
+
+		Expect(Validate(v, "")).To(Equal([]Issue{
+			{Severity: SeverityError, Path: "VAST/Ad[1]", Message: "must contain either InLine or Wrapper"},
+			{Severity: SeverityError, Path: "VAST/Ad[2]", Message: "must contain either InLine or Wrapper, not both"},
+			{Severity: SeverityError, Path: "VAST/Ad[3]/InLine/Creatives/Creative[1]/Linear", Message: "missing Duration"},
+			{Severity: SeverityError, Path: "VAST/Ad[3]/InLine/Creatives/Creative[1]/Linear", Message: "missing MediaFiles"},
+			{Severity: SeverityError, Path: "VAST/Ad[3]/InLine/Creatives/Creative[1]/Linear/TrackingEvents/Tracking[1]", Message: "progress event requires an offset"},
+			{Severity: SeverityError, Path: "VAST/Ad[3]/InLine/Creatives/Creative[1]/Linear/Icons/Icon[1]", Message: `invalid xPosition "middle"`},
+			{Severity: SeverityError, Path: "VAST/Ad[4]/InLine/Creatives/Creative[1]/Linear/MediaFiles/MediaFile[1]", Message: "bitrate must not be combined with minBitrate or maxBitrate"},
+		}))
+	})
+
+	It("should warn about misspelled events", func() {
+		v := &VAST{Version: "3.0", Ads: []Ad{{Wrapper: &Wrapper{
+			AdSystem:     &AdSystem{Name: "test"},
+			VASTAdTagURI: TagURI{Name: "http://example.com/vast.xml"},
+			Impressions:  []Impression{{URI: "http://example.com/imp"}},
+			Creatives: []CreativeWrapper{{Linear: &LinearWrapper{TrackingEvents: []Tracking{
+				{Event: "firstquartile", URI: "http://example.com/q1"},
+				{Event: "firstQuart", URI: "http://example.com/q1"},
+			}}}},
+		}}}}
+
+		Expect(Validate(v, "")).To(Equal([]Issue{
+			{Severity: SeverityWarning, Path: "VAST/Ad[1]/Wrapper/Creatives/Creative[1]/Linear/TrackingEvents/Tracking[1]", Message: `event "firstquartile" should be spelled "firstQuartile"`},
+			{Severity: SeverityWarning, Path: "VAST/Ad[1]/Wrapper/Creatives/Creative[1]/Linear/TrackingEvents/Tracking[2]", Message: `unknown event "firstQuart"`},
+		}))
+	})
+
+	It("should apply version specific rules", func() {
+		v, err := FromXML(mustReadFile("testdata/vast_inline_linear.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		issues := Validate(v, "4.1")
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[0].String()).To(Equal("warning: VAST/Ad[1]/InLine: missing AdServingId"))
+		Expect(issues[1].String()).To(Equal("error: VAST/Ad[1]/InLine/Creatives/Creative[1]: missing UniversalAdId"))
+	})
+
+})
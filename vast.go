@@ -1,14 +1,21 @@
 // Package vast implements IAB VAST 3.0 specification http://www.iab.net/media/file/VASTv3.0.pdf
+// along with the additions of VAST 4.x https://iabtechlab.com/standards/vast/
 package vast
 
 import (
 	"bytes"
 	"encoding/xml"
+	"sort"
 	"strings"
 )
 
-// MarshalXML is a custom XML marshalling method, with some fixes on top of the native encoding/xml package
-func (v *VAST) MarshalXML() ([]byte, error) {
+// MarshalXML is a custom XML marshalling method, with some fixes on top of the native encoding/xml package.
+// It strips all newlines and tabs from the output, use Encode to preserve them.
+// When options are given, the output is the one of Marshal with these options.
+func (v *VAST) MarshalXML(opts ...Option) ([]byte, error) {
+	if len(opts) != 0 {
+		return Marshal(v, opts...)
+	}
 	data, err := xml.Marshal(v)
 	if err != nil {
 		return nil, err
@@ -23,20 +30,50 @@ func (v *VAST) MarshalXML() ([]byte, error) {
 	return []byte(strXML), nil
 }
 
-// FromXML is a custom XML unmarshalling method, with some fixes on top of the native encoding/xml package
+// FromXML is a custom XML unmarshalling method, with some fixes on top of the native encoding/xml package.
+// It strips all newlines and tabs from the input, use Decode to preserve them.
+// Errors are reported as a *ParseError, located in the original input.
 func FromXML(xmlStr []byte) (*VAST, error) {
-	xmlStr = bytes.Replace(xmlStr, []byte("\n"), []byte(""), -1)
-	xmlStr = bytes.Replace(xmlStr, []byte("\t"), []byte(""), -1)
 	var v VAST
-	if err := xml.Unmarshal(xmlStr, &v); err != nil {
+	if err := decode(xmlStr, strip(xmlStr), &v, strippedPosition(xmlStr)); err != nil {
 		return nil, err
 	}
 	return &v, nil
 }
 
+// strip returns a copy of data without newlines and tabs
+func strip(data []byte) []byte {
+	data = bytes.Replace(data, []byte("\n"), []byte(""), -1)
+	return bytes.Replace(data, []byte("\t"), []byte(""), -1)
+}
+
+// strippedPosition maps offsets in orig stripped from newlines and tabs to
+// offsets in orig
+func strippedPosition(orig []byte) func(offset int64) int64 {
+	// the offsets in the stripped document at which bytes were removed
+	var removed []int64
+	for i, c := range orig {
+		if c == '\n' || c == '\t' {
+			removed = append(removed, int64(i-len(removed)))
+		}
+	}
+
+	return func(offset int64) int64 {
+		if offset <= 0 {
+			return 0
+		}
+		// the bytes removed before the byte preceding offset
+		n := sort.Search(len(removed), func(i int) bool { return removed[i] >= offset })
+		if pos := offset + int64(n); pos < int64(len(orig)) {
+			return pos
+		}
+		return int64(len(orig))
+	}
+}
+
 // VAST is the root <VAST> tag
 type VAST struct {
-	// The version of the VAST spec (should be either "2.0" or "3.0")
+	// The version of the VAST spec (should be either "2.0", "3.0" or "4.x")
 	Version string `xml:"version,attr"`
 	// One or more Ad elements. Advertisers and video content publishers may
 	// associate an <Ad> element with a line item video ad defined in contract
@@ -61,6 +98,9 @@ type Ad struct {
 	Sequence int      `xml:"sequence,attr,omitempty"`
 	InLine   *InLine  `xml:",omitempty"`
 	Wrapper  *Wrapper `xml:",omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // InLine is a vast <InLine> ad element containing actual ad definition
@@ -73,6 +113,12 @@ type InLine struct {
 	AdSystem *AdSystem
 	// The common name of the ad
 	AdTitle *AdTitle
+	// A string that identifies the ad serving transaction, used to correlate
+	// events across the ad supply chain (VAST 4).
+	AdServingID string `xml:"AdServingId,omitempty"`
+	// Categories of the advertising content, as defined by the category
+	// authority (VAST 4).
+	Categories []Category `xml:"Category,omitempty"`
 	// A string value that provides a longer description of the ad.
 	Description string `xml:",omitempty"`
 	// The name of the advertiser as defined by the ad serving party.
@@ -80,11 +126,11 @@ type InLine struct {
 	// competitors. Ad serving parties and publishers should identify how
 	// to interpret values provided within this element. As with any optional
 	// elements, the video player is not required to support it.
-	Advertiser string `xml:",omitempty"`
+	Advertiser *Advertiser `xml:",omitempty"`
 	// Provides a value that represents a price that can be used by real-time bidding
 	// (RTB) systems. VAST is not designed to handle RTB since other methods exist,
 	// but this element is offered for custom solutions if needed.
-	Pricing string `xml:",omitempty"`
+	Pricing *Pricing `xml:",omitempty"`
 	// A URI to a survey vendor that could be the survey, a tracking pixel,
 	// or anything to do with the survey. Multiple survey elements can be provided.
 	// A type attribute is available to specify the MIME type being served.
@@ -98,14 +144,22 @@ type InLine struct {
 	// One or more URIs that directs the video player to a tracking resource file that the
 	// video player should request when the first frame of the ad is displayed
 	Impressions []Impression `xml:"Impression"`
+	// URIs to request once the viewability of the ad has been determined (VAST 4).
+	ViewableImpression *ViewableImpression `xml:",omitempty"`
+	// The resources needed by verification vendors to measure the ad (VAST 4).
+	AdVerifications *AdVerifications `xml:",omitempty"`
 	// The container for one or more <Creative> elements
 	Creatives []Creative `xml:"Creatives>Creative"`
+	// The number of seconds in which the ad is valid for execution (VAST 4).
+	Expires int `xml:",omitempty"`
 	// XML node for custom extensions, as defined by the ad server. When used, a
 	// custom element should be nested under <Extensions> to help separate custom
 	// XML elements from VAST elements. The following example includes a custom
 	// xml element within the Extensions element.
 	Extensions *Extensions `xml:",omitempty"`
-	// Custom XML node for SuperSonic social links
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 type Error struct {
@@ -124,7 +178,7 @@ type Impression struct {
 // exist,  but this element is offered for custom solutions if needed.
 type Pricing struct {
 	// Identifies the pricing model as one of "cpm", "cpc", "cpe" or "cpv".
-	Model string `xml:"model,attr"`
+	Model PricingModel `xml:"model,attr"`
 	// The 3 letter ISO-4217 currency symbol that identifies the currency of
 	// the value provided
 	Currency string `xml:"currency,attr"`
@@ -152,6 +206,9 @@ type Wrapper struct {
 	// One or more URIs that directs the video player to a tracking resource file that the
 	// video player should request when the first frame of the ad is displayed
 	Impressions []Impression `xml:"Impression"`
+	// The price of the ad. Only the price of the first wrapper of a chain
+	// need be considered (VAST 4).
+	Pricing *Pricing `xml:",omitempty"`
 	// The container for one or more <Creative> elements
 	Creatives []CreativeWrapper `xml:"Creatives>Creative"`
 	// XML node for custom extensions, as defined by the ad server. When used, a
@@ -159,6 +216,22 @@ type Wrapper struct {
 	// XML elements from VAST elements. The following example includes a custom
 	// xml element within the Extensions element.
 	Extensions *Extensions `xml:",omitempty"`
+	// URIs to request once the viewability of the ad has been determined (VAST 4).
+	ViewableImpression *ViewableImpression `xml:",omitempty"`
+	// The resources needed by verification vendors to measure the ad (VAST 4).
+	AdVerifications *AdVerifications `xml:",omitempty"`
+	// Whether subsequent wrappers may be followed, true if unset (VAST 4).
+	FollowAdditionalWrappers *bool `xml:"followAdditionalWrappers,attr,omitempty"`
+	// Whether multiple ads may be returned by the wrapped tag, in which case
+	// they are all played, false if unset so that only the first stand-alone
+	// ad is played (VAST 4).
+	AllowMultipleAds *bool `xml:"allowMultipleAds,attr,omitempty"`
+	// Whether another ad of the response may be played when the wrapped tag
+	// returns no ads (VAST 4).
+	FallbackOnNoAd *bool `xml:"fallbackOnNoAd,attr,omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 type TagURI struct {
@@ -175,6 +248,85 @@ type AdTitle struct {
 	Name string `xml:",cdata"`
 }
 
+// Advertiser is the name of the advertiser as defined by the ad serving party
+type Advertiser struct {
+	// An identifier for the advertiser, provided by the ad server (VAST 4.1).
+	ID   string `xml:"id,attr,omitempty"`
+	Name string `xml:",chardata"`
+}
+
+// Category is a code that identifies the category of the ad content
+type Category struct {
+	// A URL for the organization that defines the category codes
+	Authority string `xml:"authority,attr"`
+	Code      string `xml:",chardata"`
+}
+
+// ViewableImpression contains URIs to request based on the viewability of the
+// ad, as determined by the player
+type ViewableImpression struct {
+	// An ad server-defined identifier
+	ID string `xml:"id,attr,omitempty"`
+	// URIs to request when the ad meets the criteria for a viewable impression
+	Viewable []URI `xml:"Viewable,omitempty"`
+	// URIs to request when the ad does not meet the viewability criteria
+	NotViewable []URI `xml:"NotViewable,omitempty"`
+	// URIs to request when viewability cannot be determined
+	ViewUndetermined []URI `xml:"ViewUndetermined,omitempty"`
+}
+
+// AdVerifications contains the resources needed by verification vendors
+type AdVerifications struct {
+	Verifications []Verification `xml:"Verification,omitempty"`
+}
+
+// Verification contains the resources and metadata required to execute
+// third-party measurement code in order to verify creative playback
+type Verification struct {
+	// An identifier for the verification vendor
+	Vendor string `xml:"vendor,attr,omitempty"`
+	// Container for the JavaScript resources used to collect verification data
+	JavaScriptResource []JavaScriptResource `xml:",omitempty"`
+	// Container for the executable resources used to collect verification data
+	ExecutableResource []ExecutableResource `xml:",omitempty"`
+	// Events specific to the verification vendor
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	// Parameters passed to the verification vendor code
+	VerificationParameters *VerificationParameters `xml:",omitempty"`
+}
+
+// JavaScriptResource is a reference to verification JavaScript code
+type JavaScriptResource struct {
+	// The API framework used to execute the resource, e.g. "omid"
+	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	// Whether the resource may be loaded outside of a browser environment
+	BrowserOptional bool `xml:"browserOptional,attr,omitempty"`
+	URI             URI  `xml:",cdata"`
+}
+
+// ExecutableResource is a reference to non-JavaScript verification code
+type ExecutableResource struct {
+	// The API framework used to execute the resource
+	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	// The type of executable resource
+	Type string `xml:"type,attr,omitempty"`
+	URI  URI    `xml:",cdata"`
+}
+
+// VerificationParameters contains arbitrary data passed to the verification code
+type VerificationParameters struct {
+	Parameters []byte `xml:",cdata"`
+}
+
+// UniversalAdID identifies the creative across multiple systems and platforms
+type UniversalAdID struct {
+	// The URL of the registry that issued the identifier, e.g. "ad-id.org"
+	IDRegistry string `xml:"idRegistry,attr"`
+	// The creative identifier, only used by VAST 4.0
+	IDValue string `xml:"idValue,attr,omitempty"`
+	ID      string `xml:",chardata"`
+}
+
 // Creative is a file that is part of a VAST ad.
 type Creative struct {
 	// An ad server-defined identifier for the creative
@@ -183,14 +335,21 @@ type Creative struct {
 	Sequence int `xml:"sequence,attr,omitempty"`
 	// Identifies the ad with which the creative is served
 	AdID string `xml:"AdID,attr,omitempty"`
+	// Identifies the ad with which the creative is served, as spelled by VAST 4
+	AdIDV4 string `xml:"adId,attr,omitempty"`
 	// The technology used for any included API
 	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	// Identifiers of the creative, as registered with a registry (VAST 4)
+	UniversalAdIDs []UniversalAdID `xml:"UniversalAdId,omitempty"`
 	// If present, defines a linear creative
 	Linear *Linear `xml:",omitempty"`
 	// If defined, defins companions creatives
 	CompanionAds *CompanionAds `xml:",omitempty"`
 	// If defined, defins non linear creatives
 	NonLinearAds *NonLinearAds `xml:",omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // CompanionAds contains companions creatives
@@ -217,6 +376,8 @@ type CreativeWrapper struct {
 	Sequence int `xml:"sequence,attr,omitempty"`
 	// Identifies the ad with which the creative is served
 	AdID string `xml:"AdID,attr,omitempty"`
+	// Identifies the ad with which the creative is served, as spelled by VAST 4
+	AdIDV4 string `xml:"adId,attr,omitempty"`
 	// If present, defines a linear creative
 	Linear *LinearWrapper `xml:",omitempty"`
 	// If defined, defins companions creatives
@@ -259,17 +420,20 @@ type Linear struct {
 	SkipOffset *Offset `xml:"skipoffset,attr,omitempty"`
 	// Duration in standard time format, hh:mm:ss
 	Duration           *Duration
-	Icons              []Icon
+	Icons              *Icons              `xml:",omitempty"`
 	TrackingEvents     []Tracking          `xml:"TrackingEvents>Tracking,omitempty"`
 	AdParameters       *AdParameters       `xml:",omitempty"`
 	VideoClicks        *VideoClicks        `xml:",omitempty"`
 	MediaFiles         []MediaFile         `xml:"MediaFiles>MediaFile,omitempty"`
 	CreativeExtensions *CreativeExtensions `xml:",omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // LinearWrapper defines a wrapped linear creative
 type LinearWrapper struct {
-	Icons              []Icon
+	Icons              *Icons              `xml:",omitempty"`
 	TrackingEvents     []Tracking          `xml:"TrackingEvents>Tracking,omitempty"`
 	VideoClicks        *VideoClicks        `xml:",omitempty"`
 	CreativeExtensions *CreativeExtensions `xml:",omitempty"`
@@ -290,6 +454,8 @@ type Companion struct {
 	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
 	// URL to open as destination page when user clicks on the the companion banner ad.
 	CompanionClickThrough *CompanionClickThrough `xml:",omitempty"`
+	// URLs to ping when user clicks on the the companion banner ad.
+	CompanionClickTracking []CompanionClickTracking `xml:",omitempty"`
 	// Alt text to be displayed when companion is rendered in HTML environment.
 	AltText string `xml:",omitempty"`
 	// Data to be passed into the companion ads. The apiFramework defines the method
@@ -313,6 +479,9 @@ type Companion struct {
 	APIFramework string `xml:"apiFramework,attr,omitempty"`
 	// Used to match companion creative to publisher placement areas on the page.
 	AdSlotID string `xml:"adSlotId,attr,omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // CompanionWrapper defines a companion ad in a wrapper
@@ -338,7 +507,7 @@ type CompanionWrapper struct {
 	// URL to open as destination page when user clicks on the the companion banner ad.
 	CompanionClickThrough string `xml:",omitempty"`
 	// URLs to ping when user clicks on the the companion banner ad.
-	CompanionClickTracking []string `xml:",omitempty"`
+	CompanionClickTracking []CompanionClickTracking `xml:",omitempty"`
 	// Alt text to be displayed when companion is rendered in HTML environment.
 	AltText string `xml:",omitempty"`
 	// The creativeView should always be requested when present. For Companions
@@ -390,6 +559,9 @@ type NonLinear struct {
 	MinSuggestedDuration *Duration `xml:"minSuggestedDuration,attr,omitempty"`
 	// The apiFramework defines the method to use for communication with the nonlinear element.
 	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // NonLinearWrapper defines a non linear ad in a wrapper
@@ -428,10 +600,10 @@ type Icon struct {
 	IFrameResource string `xml:",omitempty"`
 	// HTML to display the companion element
 	HTMLResource *HTMLResource `xml:",omitempty"`
-	// URLs to ping when user clicks on the the icon.
-	IconClickTrackings []string `xml:"IconClicks>IconClickTracking,omitempty"`
-	// URL to open as destination page when user clicks on the icon.
-	IconClickThrough string `xml:"IconClicks>IconClickThrough,omitempty"`
+	// The click through and click trackers of the icon.
+	IconClicks *IconClicks `xml:",omitempty"`
+	// URLs to ping when the icon is displayed.
+	IconViewTracking []URI `xml:",omitempty"`
 	// Identifies the industry initiative that the icon supports.
 	Program string `xml:"program,attr"`
 	// Pixel dimensions of icon.
@@ -440,16 +612,54 @@ type Icon struct {
 	Height int `xml:"height,attr"`
 	// The horizontal alignment location (in pixels) or a specific alignment.
 	// Must match ([0-9]*|left|right)
-	XPosition string `xml:"xPosition,attr"`
+	XPosition IconPosition `xml:"xPosition,attr"`
 	// The vertical alignment location (in pixels) or a specific alignment.
 	// Must match ([0-9]*|top|bottom)
-	YPosition string `xml:"xPosition,attr"`
+	YPosition IconPosition `xml:"yPosition,attr"`
 	// Start time at which the player should display the icon. Expressed in standard time format hh:mm:ss.
-	Offset Offset `xml:"offset,attr"`
+	Offset *Offset `xml:"offset,attr,omitempty"`
 	// duration for which the player must display the icon. Expressed in standard time format hh:mm:ss.
-	Duration string `xml:"duration,attr"`
+	Duration *Duration `xml:"duration,attr,omitempty"`
 	// The apiFramework defines the method to use for communication with the icon element
 	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	// The pixel ratio for which the icon creative is intended, 1 by default (VAST 4).
+	PxRatio float64 `xml:"pxratio,attr,omitempty"`
+	// Alternative text for the image (VAST 4.2).
+	AltText string `xml:"altText,attr,omitempty"`
+}
+
+// Icons contains the icons of a linear creative
+type Icons struct {
+	Icons []Icon `xml:"Icon,omitempty"`
+}
+
+// IconClicks contains the click through and click trackers of an icon
+type IconClicks struct {
+	// Images to display when the click through of the icon can't be opened,
+	// such as on connected TVs (VAST 4).
+	IconClickFallbackImages *IconClickFallbackImages `xml:",omitempty"`
+	// URL to open as destination page when user clicks on the icon.
+	IconClickThrough string `xml:",omitempty"`
+	// URLs to ping when user clicks on the the icon.
+	IconClickTrackings []string `xml:"IconClickTracking,omitempty"`
+}
+
+// IconClickFallbackImages contains the fallback images of an icon
+type IconClickFallbackImages struct {
+	Images []IconClickFallbackImage `xml:"IconClickFallbackImage,omitempty"`
+}
+
+// IconClickFallbackImage is an image displayed when the click through of an
+// icon can't be opened
+type IconClickFallbackImage struct {
+	// Pixel dimensions of the image.
+	Width int `xml:"width,attr,omitempty"`
+	// Pixel dimensions of the image.
+	Height int `xml:"height,attr,omitempty"`
+	// Alternative text for the image.
+	AltText string `xml:",omitempty"`
+	// URL to the image.
+	StaticResource *StaticResource `xml:",omitempty"`
 }
 
 // Tracking defines an event tracking URL
@@ -460,7 +670,7 @@ type Tracking struct {
 	// Possible values are creativeView, start, firstQuartile, midpoint, thirdQuartile,
 	// complete, mute, unmute, pause, rewind, resume, fullscreen, exitFullscreen, expand,
 	// collapse, acceptInvitation, close, skip, progress.
-	Event string `xml:"event,attr"`
+	Event EventType `xml:"event,attr"`
 	// The time during the video at which this url should be pinged. Must be present for
 	// progress event. Must match (\d{2}:[0-5]\d:[0-5]\d(\.\d\d\d)?|1?\d?\d(\.?\d)*%)
 	Offset *Offset `xml:"offset,attr,omitempty"`
@@ -538,6 +748,9 @@ type MediaFile struct {
 	// placed in key/value pairs on the asset request).
 	APIFramework string `xml:"apiFramework,attr,omitempty"`
 	URI          URI    `xml:",cdata"`
+	// Attributes and elements which are not part of the model, captured by
+	// FromXMLPreserving and DecodePreserving
+	Unknown *Unknown `xml:"-"`
 }
 
 // Extensions defines extensions
@@ -560,3 +773,9 @@ type CompanionClickThrough struct {
 	// URL to a static file, such as an image or SWF file
 	URI URI `xml:",cdata"`
 }
+
+// CompanionClickTracking defines a URL to ping when the user clicks on a companion
+type CompanionClickTracking struct {
+	ID  string `xml:"id,attr,omitempty"`
+	URI URI    `xml:",cdata"`
+}
@@ -0,0 +1,77 @@
+package vast
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VAST 4", func() {
+
+	It("should parse and round-trip VAST 4 elements", func() {
+		v, err := FromXML(mustReadFile("testdata/vast4_inline_linear.xml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Version).To(Equal("4.2"))
+
+		in := v.Ads[0].InLine
+		Expect(in.AdServingID).To(Equal("a532d16d-4d7f-4440-bd29-2ec05553fc80"))
+		Expect(in.Advertiser).To(Equal(&Advertiser{ID: "adv-1", Name: "IAB Sample Company"}))
+		Expect(in.Pricing).To(Equal(&Pricing{Model: "CPM", Currency: "USD", Value: " 25.00 "}))
+		Expect(in.Pricing.Model.Normalize()).To(Equal(PricingModelCPM))
+		Expect(in.Categories).To(Equal([]Category{
+			{Authority: "https://www.iabtechlab.com/categoryauthority", Code: "IAB1-1"},
+			{Authority: "https://www.iabtechlab.com/categoryauthority", Code: "IAB1-2"},
+		}))
+		Expect(in.Expires).To(Equal(3600))
+		Expect(in.ViewableImpression).To(Equal(&ViewableImpression{
+			ID:               "1543",
+			Viewable:         []URI{"https://example.com/viewable"},
+			NotViewable:      []URI{"https://example.com/notviewable"},
+			ViewUndetermined: []URI{"https://example.com/viewundetermined"},
+		}))
+		Expect(in.AdVerifications).To(Equal(&AdVerifications{Verifications: []Verification{{
+			Vendor: "company.com-omid",
+			JavaScriptResource: []JavaScriptResource{
+				{APIFramework: "omid", BrowserOptional: true, URI: "https://verificationcompany.com/omid.js"},
+			},
+			TrackingEvents: []Tracking{
+				{Event: "verificationNotExecuted", URI: "https://verificationcompany.com/notexecuted?reason=[REASON]"},
+			},
+			VerificationParameters: &VerificationParameters{Parameters: []byte(`{"placementId":"123"}`)},
+		}}}))
+		Expect(in.Creatives[0].AdIDV4).To(Equal("2447226"))
+		Expect(in.Creatives[0].UniversalAdIDs).To(Equal([]UniversalAdID{{IDRegistry: "Ad-ID", ID: "8465"}}))
+		Expect(in.Creatives[0].Linear.Duration).To(Equal(durationPtr(16 * time.Second)))
+		Expect(in.Creatives[0].Linear.Icons).To(Equal(&Icons{Icons: []Icon{{
+			Program:        "AdChoices",
+			Width:          16,
+			Height:         16,
+			XPosition:      IconPosition{Align: IconAlignRight},
+			YPosition:      IconPosition{Pixels: 10},
+			Offset:         &Offset{Duration: durationPtr(2 * time.Second)},
+			Duration:       durationPtr(5 * time.Second),
+			PxRatio:        2,
+			AltText:        "Ad choices",
+			StaticResource: &StaticResource{CreativeType: "image/png", URI: "https://example.com/adchoices.png"},
+			IconClicks: &IconClicks{
+				IconClickFallbackImages: &IconClickFallbackImages{Images: []IconClickFallbackImage{{
+					Width:          300,
+					Height:         250,
+					AltText:        "Why this ad",
+					StaticResource: &StaticResource{CreativeType: "image/png", URI: "https://example.com/why.png"},
+				}}},
+				IconClickThrough:   "https://example.com/adchoices",
+				IconClickTrackings: []string{"https://example.com/adchoices/click"},
+			},
+			IconViewTracking: []URI{"https://example.com/adchoices/view"},
+		}}}))
+
+		b, err := v.MarshalXML()
+		Expect(err).NotTo(HaveOccurred())
+		v2, err := FromXML(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v2).To(Equal(v))
+	})
+
+})
@@ -301,6 +301,25 @@ var _ = Describe("VAST", func() {
 		}),
 	)
 
+	It("should build schema-valid documents", func() {
+		v, err := NewInLine("1").
+			System("adserver", "1.0").
+			Title("title").
+			Impression("http://example.com/impression").
+			Linear(15*time.Second).
+			MediaFile(MediaFile{Delivery: "progressive", Type: "video/mp4", Width: 1280, Height: 720, URI: "http://example.com/ad.mp4"}).
+			Track(EventStart, "http://example.com/start").
+			ClickThrough("http://example.com/landing").
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		w := new(bytes.Buffer)
+		Expect(Encode(w, v)).To(Succeed())
+		d, err := libxml2.ParseReader(w)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema.Validate(d)).To(Succeed())
+	})
+
 })
 
 // --------------------------------------------------------------------
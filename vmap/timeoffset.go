@@ -0,0 +1,60 @@
+package vmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/trafficstars/vast"
+)
+
+// TimeOffset represents the timing of an ad break: either the start or the
+// end of the content, a cue point position, or a vast.Offset expressed as a
+// time or a percentage of the content duration.
+type TimeOffset struct {
+	// If true, the break plays before the content ("start")
+	Start bool
+	// If true, the break plays after the content ("end")
+	End bool
+	// If greater than zero, the break plays at the nth cue point of the
+	// content ("#n")
+	Position int
+	// Otherwise, the break plays at the given offset
+	Offset vast.Offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (o TimeOffset) MarshalText() ([]byte, error) {
+	switch {
+	case o.Start:
+		return []byte("start"), nil
+	case o.End:
+		return []byte("end"), nil
+	case o.Position > 0:
+		return []byte(fmt.Sprintf("#%d", o.Position)), nil
+	}
+	return o.Offset.MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (o *TimeOffset) UnmarshalText(data []byte) error {
+	*o = TimeOffset{}
+
+	s := strings.TrimSpace(string(data))
+	switch {
+	case s == "start":
+		o.Start = true
+		return nil
+	case s == "end":
+		o.End = true
+		return nil
+	case strings.HasPrefix(s, "#"):
+		n, err := strconv.Atoi(s[1:])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid time offset: %s", data)
+		}
+		o.Position = n
+		return nil
+	}
+	return o.Offset.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,48 @@
+package vmap
+
+import (
+	"time"
+
+	"github.com/trafficstars/vast"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TimeOffset", func() {
+
+	DescribeTable("marshal",
+		func(o TimeOffset, exp string) {
+			b, err := o.MarshalText()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(exp))
+		},
+		Entry("start", TimeOffset{Start: true}, "start"),
+		Entry("end", TimeOffset{End: true}, "end"),
+		Entry("position", TimeOffset{Position: 3}, "#3"),
+		Entry("percent", TimeOffset{Offset: vast.Offset{Percent: 0.5}}, "50%"),
+		Entry("time", TimeOffset{Offset: vast.Offset{Duration: durationPtr(90 * time.Second)}}, "00:01:30"),
+	)
+
+	DescribeTable("unmarshal",
+		func(s string, exp TimeOffset) {
+			var o TimeOffset
+			Expect(o.UnmarshalText([]byte(s))).To(Succeed())
+			Expect(o).To(Equal(exp))
+		},
+		Entry("start", "start", TimeOffset{Start: true}),
+		Entry("end", "end", TimeOffset{End: true}),
+		Entry("position", "#3", TimeOffset{Position: 3}),
+		Entry("percent", "50%", TimeOffset{Offset: vast.Offset{Percent: 0.5}}),
+		Entry("time", "00:01:30.000", TimeOffset{Offset: vast.Offset{Duration: durationPtr(90 * time.Second)}}),
+	)
+
+	It("should fail to unmarshal bad inputs", func() {
+		var o TimeOffset
+		Expect(o.UnmarshalText([]byte("#0"))).To(MatchError("invalid time offset: #0"))
+		Expect(o.UnmarshalText([]byte("#x"))).To(MatchError("invalid time offset: #x"))
+		Expect(o.UnmarshalText([]byte("middle"))).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,191 @@
+// Package vmap implements IAB VMAP 1.0 specification https://www.iab.com/guidelines/digital-video-multiple-ad-playlist-vmap-1-0-1/
+package vmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"github.com/trafficstars/vast"
+)
+
+// Namespace is the XML namespace of VMAP elements
+const Namespace = "http://www.iab.net/videosuite/vmap"
+
+// FromXML parses a VMAP document
+func FromXML(data []byte) (*VMAP, error) {
+	return Decode(bytes.NewReader(data))
+}
+
+// Decode parses a VMAP document from a reader
+func Decode(r io.Reader) (*VMAP, error) {
+	var v VMAP
+	if err := xml.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// VMAP is the root <vmap:VMAP> tag
+//
+// Elements are matched by their local name when decoding, regardless of
+// the namespace prefix used by the document, and are encoded with the
+// "vmap" prefix.
+type VMAP struct {
+	XMLName xml.Name `xml:"VMAP"`
+	// The version of the VMAP spec (should be "1.0")
+	Version string `xml:"version,attr"`
+	// The ad breaks of the content, in any order
+	AdBreaks []AdBreak `xml:"AdBreak"`
+	// XML node for custom extensions
+	Extensions *Extensions `xml:",omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface. It declares the
+// vmap namespace prefix on the root element.
+func (v VMAP) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain VMAP
+
+	start = prefixed(start)
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:vmap"}, Value: Namespace})
+	return e.EncodeElement(plain(v), start)
+}
+
+// AdBreak represents a single opportunity for ad display
+type AdBreak struct {
+	// The timing of the break, either "start", "end", a time, a percentage
+	// of the content or a cue point position
+	TimeOffset TimeOffset `xml:"timeOffset,attr"`
+	// Comma separated list of the ad types allowed in the break: "linear",
+	// "nonlinear" or "display"
+	BreakType string `xml:"breakType,attr"`
+	// An optional identifier of the break
+	BreakID string `xml:"breakId,attr,omitempty"`
+	// Repeats the break at the given interval, for content of unknown
+	// duration such as live streams
+	RepeatAfter *vast.Duration `xml:"repeatAfter,attr,omitempty"`
+	// The ads to play during the break
+	AdSource *AdSource `xml:",omitempty"`
+	// Tracking of the break events: "breakStart", "breakEnd" and "error"
+	TrackingEvents *TrackingEvents `xml:",omitempty"`
+	// XML node for custom extensions
+	Extensions *Extensions `xml:",omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (b AdBreak) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain AdBreak
+	return e.EncodeElement(plain(b), prefixed(start))
+}
+
+// AdSource contains either inline VAST data, an ad tag URI or custom ad data
+type AdSource struct {
+	// An optional identifier of the ad source
+	ID string `xml:"id,attr,omitempty"`
+	// Whether multiple ads, such as a VAST pod, may be played in the break
+	AllowMultipleAds *bool `xml:"allowMultipleAds,attr,omitempty"`
+	// Whether the player should follow VAST wrappers
+	FollowRedirects *bool `xml:"followRedirects,attr,omitempty"`
+	// A VAST document embedded in the VMAP document
+	VASTAdData *VASTAdData `xml:",omitempty"`
+	// A URI to an ad response
+	AdTagURI *AdTagURI `xml:",omitempty"`
+	// Ad data in a format other than VAST
+	CustomAdData *CustomAdData `xml:",omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (s AdSource) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain AdSource
+	return e.EncodeElement(plain(s), prefixed(start))
+}
+
+// VASTAdData embeds a VAST document
+type VASTAdData struct {
+	VAST *vast.VAST `xml:"VAST"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (d VASTAdData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain VASTAdData
+	return e.EncodeElement(plain(d), prefixed(start))
+}
+
+// AdTagURI is a URI to an ad response
+type AdTagURI struct {
+	// The format of the response, e.g. "vast3"
+	TemplateType string   `xml:"templateType,attr"`
+	URI          vast.URI `xml:",cdata"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (u AdTagURI) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain AdTagURI
+	return e.EncodeElement(plain(u), prefixed(start))
+}
+
+// CustomAdData contains ad data in a format other than VAST
+type CustomAdData struct {
+	// The format of the data
+	TemplateType string `xml:"templateType,attr"`
+	Data         []byte `xml:",innerxml"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (d CustomAdData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain CustomAdData
+	return e.EncodeElement(plain(d), prefixed(start))
+}
+
+// TrackingEvents contains the tracking URLs of an ad break
+type TrackingEvents struct {
+	Tracking []Tracking `xml:"Tracking,omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (t TrackingEvents) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain TrackingEvents
+	return e.EncodeElement(plain(t), prefixed(start))
+}
+
+// Tracking defines an ad break event tracking URL
+type Tracking struct {
+	// The name of the event: "breakStart", "breakEnd" or "error"
+	Event string   `xml:"event,attr"`
+	URI   vast.URI `xml:",cdata"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (t Tracking) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Tracking
+	return e.EncodeElement(plain(t), prefixed(start))
+}
+
+// Extensions defines extensions
+type Extensions struct {
+	Extensions []Extension `xml:"Extension,omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (x Extensions) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Extensions
+	return e.EncodeElement(plain(x), prefixed(start))
+}
+
+// Extension represent aribtrary XML provided by the platform to extend the VMAP response
+type Extension struct {
+	Type string `xml:"type,attr,omitempty"`
+	Data []byte `xml:",innerxml"`
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (x Extension) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Extension
+	return e.EncodeElement(plain(x), prefixed(start))
+}
+
+// prefixed returns the start element with the "vmap" namespace prefix
+func prefixed(start xml.StartElement) xml.StartElement {
+	start.Name = xml.Name{Local: "vmap:" + start.Name.Local}
+	return start
+}
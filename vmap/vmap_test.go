@@ -0,0 +1,89 @@
+package vmap
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/trafficstars/vast"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VMAP", func() {
+	var subject *VMAP
+
+	BeforeEach(func() {
+		b, err := ioutil.ReadFile("testdata/vmap.xml")
+		Expect(err).NotTo(HaveOccurred())
+
+		subject, err = FromXML(b)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should parse", func() {
+		f := false
+		t := true
+
+		Expect(subject.Version).To(Equal("1.0"))
+		Expect(subject.AdBreaks).To(HaveLen(5))
+
+		pre := subject.AdBreaks[0]
+		Expect(pre.TimeOffset).To(Equal(TimeOffset{Start: true}))
+		Expect(pre.BreakType).To(Equal("linear"))
+		Expect(pre.BreakID).To(Equal("preroll"))
+		Expect(pre.AdSource).To(Equal(&AdSource{
+			ID:               "preroll-ad-1",
+			AllowMultipleAds: &f,
+			FollowRedirects:  &t,
+			AdTagURI:         &AdTagURI{TemplateType: "vast3", URI: "http://example.com/vast/preroll.xml"},
+		}))
+		Expect(pre.TrackingEvents).To(Equal(&TrackingEvents{Tracking: []Tracking{
+			{Event: "breakStart", URI: "http://example.com/tracking/breakStart"},
+			{Event: "error", URI: "http://example.com/tracking/error?code=[ERRORCODE]"},
+		}}))
+
+		mid := subject.AdBreaks[1]
+		Expect(mid.TimeOffset).To(Equal(TimeOffset{Offset: vast.Offset{Duration: durationPtr(10 * time.Minute)}}))
+		Expect(mid.RepeatAfter).To(Equal(durationPtr(10 * time.Minute)))
+		Expect(mid.AdSource.VASTAdData.VAST.Version).To(Equal("3.0"))
+		Expect(mid.AdSource.VASTAdData.VAST.Ads[0].InLine.Creatives[0].Linear.MediaFiles[0].URI).To(Equal(vast.URI("http://example.com/midroll.mp4")))
+
+		Expect(subject.AdBreaks[2].TimeOffset).To(Equal(TimeOffset{Offset: vast.Offset{Percent: 0.5}}))
+		Expect(subject.AdBreaks[3].TimeOffset).To(Equal(TimeOffset{Position: 2}))
+		Expect(subject.AdBreaks[3].AdSource.CustomAdData).To(Equal(&CustomAdData{TemplateType: "proprietary", Data: []byte("<Custom>data</Custom>")}))
+		Expect(subject.AdBreaks[4].TimeOffset).To(Equal(TimeOffset{End: true}))
+		Expect(subject.AdBreaks[4].Extensions.Extensions).To(Equal([]Extension{{Type: "example", Data: []byte("<Example>value</Example>")}}))
+	})
+
+	It("should round-trip", func() {
+		b, err := xml.Marshal(subject)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(HavePrefix(`<vmap:VMAP xmlns:vmap="http://www.iab.net/videosuite/vmap" version="1.0"><vmap:AdBreak timeOffset="start" breakType="linear" breakId="preroll">`))
+
+		v, err := FromXML(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).To(Equal(subject))
+	})
+
+	It("should match other namespace prefixes", func() {
+		v, err := FromXML([]byte(`<x:VMAP xmlns:x="http://www.iab.net/videosuite/vmap" version="1.0"><x:AdBreak timeOffset="end" breakType="linear"/></x:VMAP>`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.AdBreaks).To(Equal([]AdBreak{{TimeOffset: TimeOffset{End: true}, BreakType: "linear"}}))
+	})
+
+})
+
+// --------------------------------------------------------------------
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "vast/vmap")
+}
+
+func durationPtr(d time.Duration) *vast.Duration {
+	v := vast.Duration(d)
+	return &v
+}